@@ -0,0 +1,76 @@
+package scratch
+
+import (
+	"errors"
+)
+
+// ErrBatchFull is returned by Batcher.AddMarshaler when adding a message would exceed the batch's size limit.
+var ErrBatchFull = errors.New("scratch: batch full")
+
+// Batcher packs messages into a buffer in the delimited format of Buf.MarshalDelimited, up to a size limit,
+// e.g. to batch writes to a message queue.
+//
+//	for _, msg := range msgs {
+//		err := batch.AddMarshaler(msg)
+//		if err == ErrBatchFull {
+//			flush(batch.Bytes())
+//			batch.Reset()
+//			err = batch.AddMarshaler(msg)
+//		}
+//		...
+//	}
+type Batcher struct {
+	b   *Buf
+	max int
+	n   int
+}
+
+// AddMarshaler appends msg to the batch, as by Buf.MarshalDelimited.
+// If that would make the batch exceed its size limit, msg is rolled back and ErrBatchFull is returned,
+// so the batch can be flushed before retrying. If the batch is empty, ErrTooLarge is returned instead,
+// as msg alone exceeds the limit.
+//
+// If marshaling fails, msg is rolled back and the error is returned.
+func (bt *Batcher) AddMarshaler(msg SizedMarshaler) error {
+	n := bt.b.Len()
+	err := bt.b.MarshalDelimited(msg)
+	if err == nil && bt.b.Len() > bt.max {
+		err = ErrBatchFull
+		if bt.n == 0 {
+			err = ErrTooLarge
+		}
+	}
+	if err != nil {
+		bt.b.ResetTo(n)
+		return err
+	}
+	bt.n++
+	return nil
+}
+
+// Len returns the number of messages in the batch.
+func (bt *Batcher) Len() int {
+	return bt.n
+}
+
+// Size returns the size of the batch in bytes.
+func (bt *Batcher) Size() int {
+	return bt.b.Len()
+}
+
+// Bytes returns the content of the batch.
+func (bt *Batcher) Bytes() []byte {
+	return bt.b.Bytes()
+}
+
+// Reset empties the batch, e.g. after flushing it.
+func (bt *Batcher) Reset() {
+	bt.b.Reset()
+	bt.n = 0
+}
+
+// NewBatcher returns a new Batcher packing messages into b, which is reset, up to max bytes.
+func NewBatcher(b *Buf, max int) *Batcher {
+	b.Reset()
+	return &Batcher{b: b, max: max}
+}
@@ -0,0 +1,32 @@
+package scratch
+
+import (
+	"io"
+	"testing"
+)
+
+func TestBatcher(t *testing.T) {
+	batch := NewBatcher(NewBuf(0), 10)
+	for _, m := range []backToFrontMsg{{"abcd", 0}, {"efgh", 0}} {
+		if err := batch.AddMarshaler(m); err != nil {
+			t.Fatalf("AddMarshaler() returns error %v", err)
+		}
+	}
+	if err := batch.AddMarshaler(backToFrontMsg{"ijkl", 0}); err != ErrBatchFull {
+		t.Fatalf("AddMarshaler() beyond the limit returns error %v instead of %v", err, ErrBatchFull)
+	}
+	if err := batch.AddMarshaler(failingMsg{}); err != io.ErrUnexpectedEOF {
+		t.Fatalf("AddMarshaler() of a failing message returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	if n, sz, s := batch.Len(), batch.Size(), string(batch.Bytes()); n != 2 || sz != 10 || s != "\x04abcd\x04efgh" {
+		t.Fatalf("the batch has (%d, %d, %q) instead of (2, 10, %q)", n, sz, s, "\x04abcd\x04efgh")
+	}
+
+	batch.Reset()
+	if err := batch.AddMarshaler(backToFrontMsg{"too large!", 0}); err != ErrTooLarge {
+		t.Fatalf("AddMarshaler() beyond the limit of an empty batch returns error %v instead of %v", err, ErrTooLarge)
+	}
+	if n := batch.Size(); n != 0 {
+		t.Fatalf("AddMarshaler() beyond the limit leaves %d bytes in the batch", n)
+	}
+}
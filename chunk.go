@@ -0,0 +1,23 @@
+package scratch
+
+import (
+	"strconv"
+)
+
+// AppendChunk appends data to the buffer as a chunk of an HTTP/1.1 chunked transfer-coded body,
+// as specified by RFC 7230 section 4.1: the length of data in hex, CRLF, data, CRLF.
+// Nothing is appended if data is empty, as an empty chunk would end the body. See AppendLastChunk.
+func (b *Buf) AppendChunk(data []byte) *Buf {
+	if len(data) == 0 {
+		return b
+	}
+	var a [16]byte
+	return b.Append(strconv.AppendUint(a[:0], uint64(len(data)), 16)).
+		AppendString("\r\n").Append(data).AppendString("\r\n")
+}
+
+// AppendLastChunk appends the last chunk of an HTTP/1.1 chunked transfer-coded body, without trailer fields,
+// i.e. "0\r\n\r\n".
+func (b *Buf) AppendLastChunk() *Buf {
+	return b.AppendString("0\r\n\r\n")
+}
@@ -0,0 +1,22 @@
+package scratch
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net/http/httputil"
+	"strings"
+	"testing"
+)
+
+func TestAppendChunk(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	sb := NewBuf(0).AppendChunk([]byte("hello")).AppendChunk(nil).AppendChunk([]byte(long)).AppendLastChunk()
+	if want := "5\r\nhello\r\n12c\r\n" + long + "\r\n0\r\n\r\n"; sb.String() != want {
+		t.Fatalf("AppendChunk() results in %q instead of %q", sb.String(), want)
+	}
+	r := httputil.NewChunkedReader(bufio.NewReader(bytes.NewReader(sb.Bytes())))
+	if p, err := ioutil.ReadAll(r); string(p) != "hello"+long || err != nil {
+		t.Fatalf("decoding the chunks returns (%q, %v)", p, err)
+	}
+}
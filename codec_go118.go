@@ -0,0 +1,27 @@
+//go:build go1.18
+// +build go1.18
+
+package scratch
+
+// Codec defines the binary layout of a record type T, centralizing its encoding and decoding.
+// See NewCodec, EncodeRecord and DecodeRecord.
+type Codec[T any] struct {
+	encode func(b *Buf, v T)
+	decode func(r *Reader) (T, error)
+}
+
+// NewCodec returns a new Codec that encodes records with encode and decodes them with decode.
+func NewCodec[T any](encode func(b *Buf, v T), decode func(r *Reader) (T, error)) Codec[T] {
+	return Codec[T]{encode: encode, decode: decode}
+}
+
+// EncodeRecord appends v to b, encoded with c.
+func EncodeRecord[T any](b *Buf, c Codec[T], v T) *Buf {
+	c.encode(b, v)
+	return b
+}
+
+// DecodeRecord reads a record from r, decoded with c.
+func DecodeRecord[T any](r *Reader, c Codec[T]) (T, error) {
+	return c.decode(r)
+}
@@ -0,0 +1,39 @@
+//go:build go1.18
+// +build go1.18
+
+package scratch
+
+import (
+	"testing"
+)
+
+type point struct {
+	x, y uint32
+}
+
+var pointCodec = NewCodec(
+	func(b *Buf, p point) {
+		b.PutUint32(p.x).PutUint32(p.y)
+	},
+	func(r *Reader) (p point, err error) {
+		if p.x, err = r.ReadUint32(); err != nil {
+			return p, err
+		}
+		p.y, err = r.ReadUint32()
+		return p, err
+	},
+)
+
+func TestCodec(t *testing.T) {
+	pts := []point{{1, 2}, {3, 4}}
+	sb := NewBuf(0)
+	for _, p := range pts {
+		EncodeRecord(sb, pointCodec, p)
+	}
+	r := NewReader(sb.Bytes())
+	for _, want := range pts {
+		if p, err := DecodeRecord(r, pointCodec); p != want || err != nil {
+			t.Fatalf("DecodeRecord() returns (%v, %v) instead of (%v, nil)", p, err, want)
+		}
+	}
+}
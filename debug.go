@@ -0,0 +1,64 @@
+//go:build scratchdebug
+// +build scratchdebug
+
+package scratch
+
+import (
+	"unsafe"
+)
+
+const (
+	// guardSize is the number of guard bytes allocated past the capacity of buffers allocated by Grow.
+	guardSize = 8
+	// guardByte is the value of each guard byte.
+	guardByte = 0xdb
+)
+
+// debugState holds the state used by the scratchdebug build to catch misuse of buffers.
+type debugState struct {
+	poisoned bool
+	guard    []byte
+}
+
+// alloc returns a new slice of length n and capacity c, followed by guard bytes.
+func (b *Buf) alloc(n, c int) []byte {
+	p := make([]byte, n, c+guardSize)
+	b.guard = p[c : c+guardSize]
+	for i := range b.guard {
+		b.guard[i] = guardByte
+	}
+	return p[:n:c]
+}
+
+// checkGuard panics if the guard bytes past the buffer's capacity were overwritten.
+// It's a no-op if the underlying slice was not allocated by Grow, e.g. after an append re-allocated it.
+func (b *Buf) checkGuard() {
+	g := b.guard
+	c := cap(b.s)
+	if g == nil || c == 0 || uintptr(unsafe.Pointer(&b.s[:c][c-1]))+1 != uintptr(unsafe.Pointer(&g[0])) {
+		b.guard = nil
+		return
+	}
+	for _, x := range g {
+		if x != guardByte {
+			panic("scratch.Buf: write past capacity detected")
+		}
+	}
+}
+
+// poison marks the buffer as returned to the pool.
+func (b *Buf) poison() {
+	b.poisoned = true
+}
+
+// unpoison marks the buffer as taken from the pool.
+func (b *Buf) unpoison() {
+	b.poisoned = false
+}
+
+// checkPoison panics if the buffer is used after being returned to the pool.
+func (b *Buf) checkPoison() {
+	if b.poisoned {
+		panic("scratch.Buf: use after Pool.Put")
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !scratchdebug
+// +build !scratchdebug
+
+package scratch
+
+// debugState is empty unless built with the scratchdebug tag.
+type debugState struct{}
+
+func (b *Buf) poison() {}
+
+func (b *Buf) unpoison() {}
+
+func (b *Buf) checkPoison() {}
+
+func (b *Buf) alloc(n, c int) []byte {
+	return make([]byte, n, c)
+}
+
+func (b *Buf) checkGuard() {}
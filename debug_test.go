@@ -0,0 +1,34 @@
+//go:build scratchdebug
+// +build scratchdebug
+
+package scratch
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestUseAfterPut(t *testing.T) {
+	pool := NewPool(8)
+	b := pool.Get()
+	pool.Put(b)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("AppendString() after Put did not panic")
+		}
+	}()
+	b.AppendString("oops")
+}
+
+func TestGuardBytes(t *testing.T) {
+	b := NewBuf(8)
+	b.Reset()
+	s := b.Tail(8)
+	(*[16]byte)(unsafe.Pointer(&s[0]))[8] = 0
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Reset() after writing past capacity did not panic")
+		}
+	}()
+	b.Reset()
+}
@@ -0,0 +1,43 @@
+package scratch
+
+// DeltaWriter appends delta-encoded integer sequences to a Buf:
+// each value is written as the zigzag varint of its difference from the previous value.
+// It's useful for compressing sorted sequences, like IDs or timestamps.
+// See DeltaReader for decoding.
+type DeltaWriter struct {
+	b    *Buf
+	last uint64
+}
+
+// AppendDelta appends v as the difference from the previous value, or from 0 for the first value.
+func (w *DeltaWriter) AppendDelta(v uint64) *DeltaWriter {
+	w.b.AppendVarint(int64(v - w.last))
+	w.last = v
+	return w
+}
+
+// NewDeltaWriter returns a new DeltaWriter appending to b.
+func NewDeltaWriter(b *Buf) *DeltaWriter {
+	return &DeltaWriter{b: b}
+}
+
+// DeltaReader reads integer sequences appended by DeltaWriter.
+type DeltaReader struct {
+	r    *Reader
+	last uint64
+}
+
+// ReadDelta reads the next value in the sequence.
+func (r *DeltaReader) ReadDelta() (uint64, error) {
+	d, err := r.r.ReadVarint()
+	if err != nil {
+		return 0, err
+	}
+	r.last += uint64(d)
+	return r.last, nil
+}
+
+// NewDeltaReader returns a new DeltaReader reading from r.
+func NewDeltaReader(r *Reader) *DeltaReader {
+	return &DeltaReader{r: r}
+}
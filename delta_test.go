@@ -0,0 +1,25 @@
+package scratch
+
+import (
+	"io"
+	"math"
+	"testing"
+)
+
+func TestDelta(t *testing.T) {
+	vs := []uint64{100, 101, 105, 105, 90, math.MaxUint64, 0}
+	sb := NewBuf(0)
+	w := NewDeltaWriter(sb)
+	for _, v := range vs {
+		w.AppendDelta(v)
+	}
+	r := NewDeltaReader(NewReader(sb.Bytes()))
+	for _, want := range vs {
+		if v, err := r.ReadDelta(); v != want || err != nil {
+			t.Fatalf("ReadDelta() returns (%d, %v) instead of (%d, nil)", v, err, want)
+		}
+	}
+	if _, err := r.ReadDelta(); err != io.EOF {
+		t.Fatalf("ReadDelta() at the end returns error %v instead of %v", err, io.EOF)
+	}
+}
@@ -0,0 +1,28 @@
+package scratch
+
+// AppendDERLength appends n to the buffer as an ASN.1 DER length:
+// in the short form, a single byte, if n is less than 128,
+// otherwise in the long form, a byte 0x80|k followed by n in k big-endian bytes, with no leading zeros.
+//
+// AppendDERLength panics if n is negative.
+func (b *Buf) AppendDERLength(n int) *Buf {
+	if n < 0 {
+		panic("scratch.Buf.AppendDERLength: negative length")
+	}
+	if n < 0x80 {
+		return b.AppendByte(byte(n))
+	}
+	k := 1
+	for n>>(8*uint(k)) != 0 {
+		k++
+	}
+	b.AppendByte(0x80 | byte(k))
+	putUintN(b.Tail(k), uint64(n))
+	return b
+}
+
+// AppendDERTLV appends an ASN.1 DER tag-length-value element to the buffer, with the single-byte tag,
+// e.g. 0x02 for an INTEGER or 0x30 for a SEQUENCE, followed by the DER length of content and content itself.
+func (b *Buf) AppendDERTLV(tag byte, content []byte) *Buf {
+	return b.AppendByte(tag).AppendDERLength(len(content)).Append(content)
+}
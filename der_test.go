@@ -0,0 +1,34 @@
+package scratch
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"testing"
+)
+
+func TestAppendDERLength(t *testing.T) {
+	tests := []struct {
+		n   int
+		out []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x81, 0x80}},
+		{255, []byte{0x81, 0xff}},
+		{256, []byte{0x82, 0x01, 0x00}},
+		{0x1000000, []byte{0x84, 0x01, 0x00, 0x00, 0x00}},
+	}
+	for _, tt := range tests {
+		if p := NewBuf(0).AppendDERLength(tt.n).Bytes(); !bytes.Equal(p, tt.out) {
+			t.Fatalf("AppendDERLength(%d) results in %#v instead of %#v", tt.n, p, tt.out)
+		}
+	}
+}
+
+func TestAppendDERTLV(t *testing.T) {
+	s := bytes.Repeat([]byte("x"), 200)
+	want, _ := asn1.Marshal(s)
+	if p := NewBuf(0).AppendDERTLV(0x04, s).Bytes(); !bytes.Equal(p, want) {
+		t.Fatalf("AppendDERTLV() results in %#v instead of %#v", p, want)
+	}
+}
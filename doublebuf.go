@@ -0,0 +1,66 @@
+package scratch
+
+import (
+	"io"
+	"sync"
+)
+
+var (
+	_ io.Writer       = (*DoubleBuffer)(nil)
+	_ io.StringWriter = (*DoubleBuffer)(nil)
+)
+
+// DoubleBuffer holds a pair of buffers, one of which is filled while the other is flushed.
+// All methods are safe for concurrent use.
+type DoubleBuffer struct {
+	mu   sync.Mutex
+	cur  *Buf
+	prev *Buf
+}
+
+// Do calls f with the active buffer while holding the lock.
+// The buffer must not be retained after f returns.
+func (d *DoubleBuffer) Do(f func(b *Buf)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f(d.cur)
+}
+
+// Write implements io.Writer by appending s to the active buffer.
+// Write never returns an error.
+func (d *DoubleBuffer) Write(s []byte) (int, error) {
+	d.mu.Lock()
+	d.cur.Append(s)
+	d.mu.Unlock()
+	return len(s), nil
+}
+
+// WriteString implements io.StringWriter by appending s to the active buffer.
+// WriteString never returns an error.
+func (d *DoubleBuffer) WriteString(s string) (int, error) {
+	d.mu.Lock()
+	d.cur.AppendString(s)
+	d.mu.Unlock()
+	return len(s), nil
+}
+
+// Swap returns the active buffer for flushing and makes the other buffer active.
+//
+// The returned buffer is owned by the caller until the next call to Swap,
+// at which point it's reset and becomes the active buffer again.
+func (d *DoubleBuffer) Swap() *Buf {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	b := d.cur
+	d.cur = d.prev.Reset()
+	d.prev = b
+	return b
+}
+
+// NewDoubleBuffer returns a new double buffer whose buffers are each capable of holding cap bytes without re-allocation.
+func NewDoubleBuffer(cap int) *DoubleBuffer {
+	return &DoubleBuffer{
+		cur:  NewBuf(cap),
+		prev: NewBuf(cap),
+	}
+}
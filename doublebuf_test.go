@@ -0,0 +1,22 @@
+package scratch
+
+import (
+	"testing"
+)
+
+func TestDoubleBufferSwap(t *testing.T) {
+	d := NewDoubleBuffer(8)
+	d.WriteString("one")
+	a := d.Swap()
+	if s := a.String(); s != "one" {
+		t.Fatalf("Swap() returns buffer containing %q instead of %q", s, "one")
+	}
+	d.WriteString("two")
+	b := d.Swap()
+	if s := b.String(); s != "two" {
+		t.Fatalf("Swap() returns buffer containing %q instead of %q", s, "two")
+	}
+	if a.Len() != 0 {
+		t.Fatalf("Swap() did not reset the previously flushed buffer, it has len %d", a.Len())
+	}
+}
@@ -0,0 +1,34 @@
+package scratch
+
+import (
+	"encoding/base32"
+)
+
+// AppendBase32 appends the base32 encoding of src to the buffer, using the encoding enc,
+// e.g. base32.StdEncoding or base32.HexEncoding.WithPadding(base32.NoPadding).
+func (b *Buf) AppendBase32(enc *base32.Encoding, src []byte) *Buf {
+	enc.Encode(b.Tail(enc.EncodedLen(len(src))), src)
+	return b
+}
+
+// AppendBaseN appends v to the buffer in the base len(alphabet), most significant digit first,
+// using the byte alphabet[d] for each digit d, e.g. a base58 or base62 alphabet for short IDs.
+// 0 is appended as alphabet[0].
+//
+// AppendBaseN panics if alphabet has fewer than 2 or more than 256 bytes.
+func (b *Buf) AppendBaseN(v uint64, alphabet string) *Buf {
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		panic("scratch.Buf.AppendBaseN: invalid alphabet size")
+	}
+	base := uint64(len(alphabet))
+	var a [64]byte
+	i := len(a)
+	for {
+		i--
+		a[i] = alphabet[v%base]
+		v /= base
+		if v == 0 {
+			return b.Append(a[i:])
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package scratch
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+)
+
+func TestAppendBase32(t *testing.T) {
+	src := []byte("hello, world")
+	for _, enc := range []*base32.Encoding{base32.StdEncoding, base32.HexEncoding.WithPadding(base32.NoPadding)} {
+		if s, want := NewBufString("id:").AppendBase32(enc, src).String(), "id:"+enc.EncodeToString(src); s != want {
+			t.Fatalf("AppendBase32() results in %q instead of %q", s, want)
+		}
+	}
+}
+
+func TestAppendBaseN(t *testing.T) {
+	const base58 = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	tests := []struct {
+		v        uint64
+		alphabet string
+		out      string
+	}{
+		{0, base58, "1"},
+		{57, base58, "z"},
+		{58, base58, "21"},
+		{255, "0123456789abcdef", "ff"},
+		{1<<64 - 1, "01", strings.Repeat("1", 64)},
+	}
+	for _, tt := range tests {
+		if s := NewBuf(0).AppendBaseN(tt.v, tt.alphabet).String(); s != tt.out {
+			t.Fatalf("AppendBaseN(%d, %q) results in %q instead of %q", tt.v, tt.alphabet, s, tt.out)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package scratch
+
+import (
+	"strings"
+)
+
+// AppendHTMLEscaped appends s to the buffer, escaping special characters like "<" to become "&lt;".
+// It escapes the same five characters as html.EscapeString: <, >, &, ' and ".
+func (b *Buf) AppendHTMLEscaped(s string) *Buf {
+	last := 0
+	for i := 0; i < len(s); i++ {
+		var esc string
+		switch s[i] {
+		case '&':
+			esc = "&amp;"
+		case '\'':
+			esc = "&#39;"
+		case '<':
+			esc = "&lt;"
+		case '>':
+			esc = "&gt;"
+		case '"':
+			esc = "&#34;"
+		default:
+			continue
+		}
+		b.AppendString(s[last:i]).AppendString(esc)
+		last = i + 1
+	}
+	return b.AppendString(s[last:])
+}
+
+// AppendQueryEscape appends s to the buffer, escaped so it can be safely placed inside a URL query.
+// It produces the same output as url.QueryEscape.
+func (b *Buf) AppendQueryEscape(s string) *Buf {
+	return b.appendURLEscaped(s, true)
+}
+
+// AppendPathEscape appends s to the buffer, escaped so it can be safely placed inside a URL path segment.
+// It produces the same output as url.PathEscape.
+func (b *Buf) AppendPathEscape(s string) *Buf {
+	return b.appendURLEscaped(s, false)
+}
+
+// AppendFormValue appends key=value to the buffer, with both query-escaped, for use in a form-urlencoded body.
+// An '&' separator is appended first, unless the buffer is empty or ends with '?' or '&',
+// so the output of successive calls matches url.Values.Encode, including after a URL prefix like "https://host/path?".
+func (b *Buf) AppendFormValue(key, value string) *Buf {
+	if n := b.Len(); n != 0 && b.s[n-1] != '?' && b.s[n-1] != '&' {
+		b.AppendByte('&')
+	}
+	return b.AppendQueryEscape(key).AppendByte('=').AppendQueryEscape(value)
+}
+
+// appendURLEscaped appends the percent-encoded form of s.
+// If query is true, it uses query component rules, otherwise path segment rules.
+func (b *Buf) appendURLEscaped(s string, query bool) *Buf {
+	const hex = "0123456789ABCDEF"
+	last := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !shouldURLEscape(c, query) {
+			continue
+		}
+		b.AppendString(s[last:i])
+		last = i + 1
+		if c == ' ' && query {
+			b.AppendByte('+')
+			continue
+		}
+		b.AppendByte('%').AppendByte(hex[c>>4]).AppendByte(hex[c&15])
+	}
+	return b.AppendString(s[last:])
+}
+
+// shouldURLEscape mirrors the rules used by net/url for query components and path segments.
+func shouldURLEscape(c byte, query bool) bool {
+	if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+		return false
+	}
+	switch c {
+	case '-', '_', '.', '~':
+		return false
+	case '$', '&', '+', ',', '/', ':', ';', '=', '?', '@':
+		if query {
+			return true
+		}
+		return c == '/' || c == ';' || c == ',' || c == '?'
+	}
+	return true
+}
+
+// AppendCSVField appends s to the buffer as an RFC 4180 CSV field.
+// The field is quoted, with internal quotes doubled, only if it contains a comma, quote, CR or LF.
+func (b *Buf) AppendCSVField(s string) *Buf {
+	if !strings.ContainsAny(s, ",\"\r\n") {
+		return b.AppendString(s)
+	}
+	b.AppendByte('"')
+	for {
+		i := strings.IndexByte(s, '"')
+		if i < 0 {
+			break
+		}
+		b.AppendString(s[:i+1]).AppendByte('"')
+		s = s[i+1:]
+	}
+	return b.AppendString(s).AppendByte('"')
+}
+
+// AppendShellQuoted appends s to the buffer, quoted so a POSIX shell treats it as a single literal word.
+// s is wrapped in single quotes, and each internal single quote closes the quoting, is escaped, and re-opens it,
+// e.g. it's is appended as:
+//
+//	'it'\''s'
+func (b *Buf) AppendShellQuoted(s string) *Buf {
+	b.AppendByte('\'')
+	for {
+		i := strings.IndexByte(s, '\'')
+		if i < 0 {
+			break
+		}
+		b.AppendString(s[:i]).AppendString(`'\''`)
+		s = s[i+1:]
+	}
+	return b.AppendString(s).AppendByte('\'')
+}
@@ -0,0 +1,71 @@
+package scratch
+
+import (
+	"html"
+	"net/url"
+	"testing"
+)
+
+func TestAppendHTMLEscaped(t *testing.T) {
+	for _, s := range []string{"", "plain", `<a href="x">Tom & Jerry's</a>`, "&&<<>>"} {
+		sb := NewBuf(0).AppendHTMLEscaped(s)
+		if p, q := sb.String(), html.EscapeString(s); p != q {
+			t.Fatalf("AppendHTMLEscaped(%q) results in %q instead of %q", s, p, q)
+		}
+	}
+}
+
+func TestAppendURLEscape(t *testing.T) {
+	for _, s := range []string{"", "plain", "a b&c=d/e?f", "$+,:;@~-_.", "héllo\x00\xff"} {
+		if p, q := NewBuf(0).AppendQueryEscape(s).String(), url.QueryEscape(s); p != q {
+			t.Fatalf("AppendQueryEscape(%q) results in %q instead of %q", s, p, q)
+		}
+		if p, q := NewBuf(0).AppendPathEscape(s).String(), url.PathEscape(s); p != q {
+			t.Fatalf("AppendPathEscape(%q) results in %q instead of %q", s, p, q)
+		}
+	}
+}
+
+func TestAppendCSVField(t *testing.T) {
+	tests := []struct{ in, out string }{
+		{"", ""},
+		{"plain", "plain"},
+		{"a,b", `"a,b"`},
+		{`say "hi"`, `"say ""hi"""`},
+		{"line\nbreak", "\"line\nbreak\""},
+	}
+	for _, tt := range tests {
+		if s := NewBuf(0).AppendCSVField(tt.in).String(); s != tt.out {
+			t.Fatalf("AppendCSVField(%q) results in %q instead of %q", tt.in, s, tt.out)
+		}
+	}
+}
+
+func TestAppendFormValue(t *testing.T) {
+	sb := NewBuf(0).AppendFormValue("a b", "1&2").AppendFormValue("c", "=")
+	want := url.Values{"a b": {"1&2"}, "c": {"="}}.Encode()
+	if s := sb.String(); s != want {
+		t.Fatalf("AppendFormValue() results in %q instead of %q", s, want)
+	}
+	for _, prefix := range []string{"https://x/?", "https://x/?q=1&"} {
+		sb := NewBufString(prefix).AppendFormValue("a", "b").AppendFormValue("c", "d")
+		if s := sb.String(); s != prefix+"a=b&c=d" {
+			t.Fatalf("AppendFormValue() after %q results in %q instead of %q", prefix, s, prefix+"a=b&c=d")
+		}
+	}
+}
+
+func TestAppendShellQuoted(t *testing.T) {
+	tests := []struct{ in, out string }{
+		{"", "''"},
+		{"plain", "'plain'"},
+		{"$(rm -rf /) `x` \\ \"", "'$(rm -rf /) `x` \\ \"'"},
+		{"it's", `'it'\''s'`},
+		{"''", `''\'''\'''`},
+	}
+	for _, tt := range tests {
+		if s := NewBuf(0).AppendShellQuoted(tt.in).String(); s != tt.out {
+			t.Fatalf("AppendShellQuoted(%q) results in %q instead of %q", tt.in, s, tt.out)
+		}
+	}
+}
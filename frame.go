@@ -0,0 +1,104 @@
+package scratch
+
+import (
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// ErrChecksum is returned when a frame's checksum doesn't match its payload.
+var ErrChecksum = errors.New("scratch: checksum mismatch")
+
+// ErrBadMagic is returned when a header doesn't start with the expected magic bytes.
+var ErrBadMagic = errors.New("scratch: bad magic")
+
+// AppendFrame appends payload to the buffer as a frame of the form:
+// [4-byte length][payload][4-byte CRC-32 (IEEE) of payload], with both integers in big-endian order.
+// See Reader.ReadFrame for decoding.
+//
+// AppendFrame panics if payload is longer than math.MaxUint32 bytes.
+func (b *Buf) AppendFrame(payload []byte) *Buf {
+	if uint64(len(payload)) > math.MaxUint32 {
+		panic("scratch.Buf.AppendFrame: payload too large")
+	}
+	return b.
+		PutUint32(uint32(len(payload))).
+		Append(payload).
+		PutUint32(crc32.ChecksumIEEE(payload))
+}
+
+// AppendHeader appends a file or stream header of the form [magic][2-byte big-endian version] to the buffer.
+// See Reader.ReadHeader for decoding.
+func (b *Buf) AppendHeader(magic []byte, version uint16) *Buf {
+	return b.Append(magic).PutUint16(version)
+}
+
+// FinalizeWithLengthPrefix prepends the buffer's current length to its content,
+// as a width-byte big-endian integer, shifting the content to make room.
+//
+// FinalizeWithLengthPrefix panics if width is not between 1 and 8, or if Len() doesn't fit in width bytes.
+func (b *Buf) FinalizeWithLengthPrefix(width int) *Buf {
+	if width < 1 || width > 8 {
+		panic("scratch.Buf.FinalizeWithLengthPrefix: width out of range")
+	}
+	n := uint64(b.Len())
+	if !fitsUintN(n, width) {
+		panic("scratch.Buf.FinalizeWithLengthPrefix: length overflows width")
+	}
+	b.Tail(width)
+	copy(b.s[width:], b.s)
+	putUintN(b.s[:width], n)
+	return b
+}
+
+// ReadFrame reads a frame as appended by Buf.AppendFrame and returns its payload.
+// It returns ErrChecksum if the checksum doesn't match the payload.
+// If an error is returned, no bytes are consumed.
+//
+// The payload is a sub-slice of the Reader's underlying slice, not a copy.
+func (r *Reader) ReadFrame() ([]byte, error) {
+	off := r.off
+	payload, err := r.readFrame()
+	if err != nil {
+		r.off = off
+	}
+	return payload, err
+}
+
+func (r *Reader) readFrame() ([]byte, error) {
+	n, err := r.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(n)+4 > uint64(r.Remaining()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	payload := r.s[r.off : r.off+int(n)]
+	r.off += int(n)
+	sum, _ := r.ReadUint32()
+	if crc32.ChecksumIEEE(payload) != sum {
+		return nil, ErrChecksum
+	}
+	return payload, nil
+}
+
+// ReadHeader reads a header as appended by Buf.AppendHeader and returns its version.
+// It returns ErrBadMagic if the header doesn't start with magic, e.g. because the data is corrupt or of another format.
+// If an error is returned, no bytes are consumed.
+func (r *Reader) ReadHeader(magic []byte) (version uint16, err error) {
+	off := r.off
+	p, err := r.next(len(magic))
+	if err == nil && !bytes.Equal(p, magic) {
+		err = ErrBadMagic
+	}
+	if err == nil {
+		version, err = r.ReadUint16()
+	}
+	if err != nil {
+		r.off = off
+		return 0, err
+	}
+	return version, nil
+}
@@ -0,0 +1,64 @@
+package scratch
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestFrame(t *testing.T) {
+	sb := NewBuf(0).AppendFrame([]byte("first")).AppendFrame(nil).AppendFrame([]byte("second"))
+	r := NewReader(sb.Bytes())
+	for _, want := range []string{"first", "", "second"} {
+		if p, err := r.ReadFrame(); string(p) != want || err != nil {
+			t.Fatalf("ReadFrame() returns (%q, %v) instead of (%q, nil)", p, err, want)
+		}
+	}
+	if _, err := r.ReadFrame(); err != io.EOF {
+		t.Fatalf("ReadFrame() at the end returns error %v instead of %v", err, io.EOF)
+	}
+
+	frame := NewBuf(0).AppendFrame([]byte("payload")).Bytes()
+	if _, err := NewReader(frame[:len(frame)-1]).ReadFrame(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ReadFrame() of a truncated frame returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	frame[5]++
+	if _, err := NewReader(frame).ReadFrame(); err != ErrChecksum {
+		t.Fatalf("ReadFrame() of a corrupt frame returns error %v instead of %v", err, ErrChecksum)
+	}
+}
+
+func TestFinalizeWithLengthPrefix(t *testing.T) {
+	sb := NewBufString("abc").FinalizeWithLengthPrefix(2)
+	if p, want := sb.Bytes(), []byte{0, 3, 'a', 'b', 'c'}; !bytes.Equal(p, want) {
+		t.Fatalf("FinalizeWithLengthPrefix(2) results in %#v instead of %#v", p, want)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("FinalizeWithLengthPrefix(1) with a length of 256 did not panic")
+		}
+	}()
+	NewBuf(0).Append(make([]byte, 256)).FinalizeWithLengthPrefix(1)
+}
+
+func TestHeader(t *testing.T) {
+	magic := []byte("SCR\x00")
+	data := NewBuf(0).AppendHeader(magic, 3).AppendByte('x').Bytes()
+	r := NewReader(data)
+	if v, err := r.ReadHeader(magic); v != 3 || err != nil {
+		t.Fatalf("ReadHeader() returns (%d, %v) instead of (3, nil)", v, err)
+	}
+	if n := r.Remaining(); n != 1 {
+		t.Fatalf("ReadHeader() leaves %d bytes instead of 1", n)
+	}
+	r = NewReader(data)
+	if _, err := r.ReadHeader([]byte("ZIP\x00")); err != ErrBadMagic {
+		t.Fatalf("ReadHeader() with another magic returns error %v instead of %v", err, ErrBadMagic)
+	}
+	if _, err := r.ReadHeader(data[:6]); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ReadHeader() of a truncated header returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	if n := r.Pos(); n != 0 {
+		t.Fatalf("ReadHeader() errors consume %d bytes", n)
+	}
+}
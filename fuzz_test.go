@@ -0,0 +1,27 @@
+//go:build go1.18
+// +build go1.18
+
+package scratch
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func FuzzVarintRoundTrip(f *testing.F) {
+	for _, n := range []uint64{0, 1, 127, 128, 1<<63 - 1, math.MaxUint64} {
+		f.Add(n)
+	}
+	f.Fuzz(func(t *testing.T, n uint64) {
+		sb := NewBuf(0).AppendUvarint(n)
+		if l := sb.Len(); l > binary.MaxVarintLen64 {
+			t.Fatalf("AppendUvarint(%d) results in %d bytes, more than the maximum of %d", n, l, binary.MaxVarintLen64)
+		}
+		r := NewReader(sb.Bytes())
+		m, err := r.ReadUvarint()
+		if m != n || err != nil {
+			t.Fatalf("ReadUvarint() returns (%d, %v) instead of (%d, nil)", m, err, n)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package scratch
+
+import (
+	"compress/gzip"
+	"sync"
+)
+
+// gzipWriters holds pools of gzip writers, indexed by compression level from gzip.HuffmanOnly to gzip.BestCompression.
+var gzipWriters [gzip.BestCompression - gzip.HuffmanOnly + 1]sync.Pool
+
+// AppendGzip appends the gzip compression of src to the buffer, at the given compression level,
+// e.g. gzip.DefaultCompression or gzip.BestSpeed.
+// If an error is returned, e.g. because level is invalid, the buffer is left unchanged.
+//
+// Gzip writers are pooled per level, so only calls that can't re-use a pooled writer allocate one.
+func (b *Buf) AppendGzip(src []byte, level int) (*Buf, error) {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		_, err := gzip.NewWriterLevel(nil, level)
+		return b, err
+	}
+	n := b.Len()
+	p := &gzipWriters[level-gzip.HuffmanOnly]
+	zw, _ := p.Get().(*gzip.Writer)
+	if zw == nil {
+		var err error
+		if zw, err = gzip.NewWriterLevel(b, level); err != nil {
+			return b, err
+		}
+	} else {
+		zw.Reset(b)
+	}
+	_, err := zw.Write(src)
+	if err == nil {
+		err = zw.Close()
+	}
+	zw.Reset(nil)
+	p.Put(zw)
+	if err != nil {
+		b.ResetTo(n)
+	}
+	return b, err
+}
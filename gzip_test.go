@@ -0,0 +1,32 @@
+package scratch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+)
+
+func TestAppendGzip(t *testing.T) {
+	src := bytes.Repeat([]byte("data"), 100)
+	for _, level := range []int{gzip.DefaultCompression, gzip.BestSpeed, gzip.DefaultCompression} {
+		sb, err := NewBufString("x").AppendGzip(src, level)
+		if err != nil {
+			t.Fatalf("AppendGzip(%d) returns error %v", level, err)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(sb.Bytes()[1:]))
+		if err != nil {
+			t.Fatalf("AppendGzip(%d) results in invalid gzip data: %v", level, err)
+		}
+		if p, err := ioutil.ReadAll(zr); err != nil || !bytes.Equal(p, src) {
+			t.Fatalf("AppendGzip(%d) results in data that decompresses to (%q, %v)", level, p, err)
+		}
+	}
+	sb, err := NewBufString("x").AppendGzip(src, 42)
+	if err == nil {
+		t.Fatal("AppendGzip(42) did not return an error")
+	}
+	if s := sb.String(); s != "x" {
+		t.Fatalf("AppendGzip(42) results in %q instead of leaving the buffer unchanged", s)
+	}
+}
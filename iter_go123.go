@@ -0,0 +1,16 @@
+//go:build go1.23
+// +build go1.23
+
+package scratch
+
+import (
+	"iter"
+)
+
+// AppendSeq appends each chunk yielded by seq to the buffer.
+func (b *Buf) AppendSeq(seq iter.Seq[[]byte]) *Buf {
+	for p := range seq {
+		b.Append(p)
+	}
+	return b
+}
@@ -0,0 +1,21 @@
+//go:build go1.23
+// +build go1.23
+
+package scratch
+
+import (
+	"testing"
+)
+
+func TestAppendSeq(t *testing.T) {
+	seq := func(yield func([]byte) bool) {
+		for _, s := range []string{"a", "bc", "def"} {
+			if !yield([]byte(s)) {
+				return
+			}
+		}
+	}
+	if s := NewBuf(0).AppendSeq(seq).String(); s != "abcdef" {
+		t.Fatalf("AppendSeq() results in %q instead of %q", s, "abcdef")
+	}
+}
@@ -0,0 +1,114 @@
+package scratch
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+// KeyEscape is the escape byte used by AppendKeyComponent.
+const KeyEscape = '\\'
+
+// ErrInvalidKey is returned by SplitKey and Reader.ReadSortableString when a key contains an invalid escape sequence.
+var ErrInvalidKey = errors.New("scratch: invalid key escape sequence")
+
+// AppendKeyComponent appends s to the buffer, with occurrences of delim and KeyEscape prefixed with KeyEscape,
+// so that the component can be separated from the next one by delim without ambiguity.
+// Keys built this way remain prefix-safe: a scan for the prefix "a/" never matches a key whose first component is "a/b".
+// See SplitKey for decoding.
+//
+// Escaped components don't sort like their raw bytes, e.g. "a/" sorts before "a0" but its escaped form `a\/` sorts after it.
+// Use AppendSortableString for keys that must sort in the order of their components.
+//
+// AppendKeyComponent panics if delim is KeyEscape.
+func (b *Buf) AppendKeyComponent(s []byte, delim byte) *Buf {
+	if delim == KeyEscape {
+		panic("scratch.Buf.AppendKeyComponent: delim is the escape byte")
+	}
+	for _, c := range s {
+		if c == delim || c == KeyEscape {
+			b.AppendByte(KeyEscape)
+		}
+		b.AppendByte(c)
+	}
+	return b
+}
+
+// SplitKey splits key into the unescaped components appended by Buf.AppendKeyComponent and separated by delim.
+// It returns ErrInvalidKey if key contains an escape sequence not appended by Buf.AppendKeyComponent,
+// i.e. if KeyEscape isn't followed by delim or KeyEscape.
+func SplitKey(key []byte, delim byte) ([][]byte, error) {
+	var parts [][]byte
+	part := []byte{}
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; c {
+		case KeyEscape:
+			i++
+			if i == len(key) || key[i] != delim && key[i] != KeyEscape {
+				return nil, ErrInvalidKey
+			}
+			part = append(part, key[i])
+		case delim:
+			parts = append(parts, part)
+			part = []byte{}
+		default:
+			part = append(part, c)
+		}
+	}
+	return append(parts, part), nil
+}
+
+// AppendSortableString appends s to the buffer as a variable-length key component that preserves sort order:
+// 0x00 bytes are escaped as 0x00 0xFF and the component is terminated by 0x00 0x01.
+// Keys made of several such components sort byte-wise in the same order as their components.
+// See Reader.ReadSortableString for decoding.
+func (b *Buf) AppendSortableString(s string) *Buf {
+	for {
+		i := strings.IndexByte(s, 0x00)
+		if i < 0 {
+			break
+		}
+		b.AppendString(s[:i]).AppendByte(0x00).AppendByte(0xFF)
+		s = s[i+1:]
+	}
+	return b.AppendString(s).AppendByte(0x00).AppendByte(0x01)
+}
+
+// ReadSortableString reads a key component as appended by Buf.AppendSortableString, and returns it unescaped.
+// It returns ErrInvalidKey if the component contains an invalid escape sequence,
+// or io.ErrUnexpectedEOF if it's not terminated.
+//
+// If the component contains no escaped bytes, the result is a sub-slice of the Reader's underlying slice, not a copy.
+func (r *Reader) ReadSortableString() ([]byte, error) {
+	if r.off == len(r.s) {
+		return nil, io.EOF
+	}
+	var out []byte
+	s := r.s[r.off:]
+	for n := 0; ; {
+		i := bytes.IndexByte(s[n:], 0x00)
+		if i < 0 || n+i+1 == len(s) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		i += n
+		switch s[i+1] {
+		case 0x01:
+			if out == nil {
+				out = s[:i:i]
+			} else {
+				out = append(out, s[n:i]...)
+			}
+			r.off += i + 2
+			return out, nil
+		case 0xFF:
+			if out == nil {
+				out = []byte{}
+			}
+			out = append(append(out, s[n:i]...), 0x00)
+			n = i + 2
+		default:
+			return nil, ErrInvalidKey
+		}
+	}
+}
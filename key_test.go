@@ -0,0 +1,63 @@
+package scratch
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestKeyComponents(t *testing.T) {
+	parts := [][]byte{[]byte("users"), []byte("a/b"), []byte(`c\d`), {}}
+	sb := NewBuf(0)
+	for i, p := range parts {
+		if i > 0 {
+			sb.AppendByte('/')
+		}
+		sb.AppendKeyComponent(p, '/')
+	}
+	if s, want := sb.String(), `users/a\/b/c\\d/`; s != want {
+		t.Fatalf("AppendKeyComponent() results in %q instead of %q", s, want)
+	}
+	got, err := SplitKey(sb.Bytes(), '/')
+	if err != nil {
+		t.Fatalf("SplitKey() returns error %v", err)
+	}
+	if len(got) != len(parts) {
+		t.Fatalf("SplitKey() returns %d components instead of %d", len(got), len(parts))
+	}
+	for i := range parts {
+		if !bytes.Equal(got[i], parts[i]) {
+			t.Fatalf("SplitKey() component %d is %q instead of %q", i, got[i], parts[i])
+		}
+	}
+	for _, key := range []string{`a\`, `a\b`} {
+		if _, err := SplitKey([]byte(key), '/'); err != ErrInvalidKey {
+			t.Fatalf("SplitKey(%q) returns error %v instead of %v", key, err, ErrInvalidKey)
+		}
+	}
+}
+
+func TestSortableString(t *testing.T) {
+	ss := []string{"", "\x00", "\x00\x00", "\x00a", "a", "a\x00", "a\x00b", "ab", "b"}
+	var prev []byte
+	for _, s := range ss {
+		key := NewBuf(0).AppendSortableString(s).AppendSortableString("next").Bytes()
+		if prev != nil && bytes.Compare(prev, key) >= 0 {
+			t.Fatalf("AppendSortableString(%q) results in %#v, which does not sort after %#v", s, key, prev)
+		}
+		prev = key
+		r := NewReader(key)
+		if p, err := r.ReadSortableString(); string(p) != s || err != nil {
+			t.Fatalf("ReadSortableString() returns (%q, %v) instead of (%q, nil)", p, err, s)
+		}
+		if p, err := r.ReadSortableString(); string(p) != "next" || err != nil {
+			t.Fatalf("ReadSortableString() returns (%q, %v) instead of (%q, nil)", p, err, "next")
+		}
+	}
+	if _, err := NewReader([]byte{'a', 0x00}).ReadSortableString(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ReadSortableString() of an unterminated component returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := NewReader([]byte{'a', 0x00, 0x02}).ReadSortableString(); err != ErrInvalidKey {
+		t.Fatalf("ReadSortableString() of an invalid escape returns error %v instead of %v", err, ErrInvalidKey)
+	}
+}
@@ -0,0 +1,75 @@
+package scratch
+
+import (
+	"bytes"
+)
+
+// KeySet is a set of keys stored contiguously in a Buf arena, for cheap de-duplication of many small keys.
+// Keys are indexed by hash, and compared by slicing back into the arena.
+//
+// The zero value is an empty set ready to use.
+type KeySet struct {
+	arena Buf
+	index map[uint64][]keyRef
+	n     int
+}
+
+// keyRef locates a key in a KeySet's arena.
+type keyRef struct {
+	off, n int
+}
+
+// Len returns the number of keys in the set.
+func (ks *KeySet) Len() int {
+	return ks.n
+}
+
+// Contains reports whether key is in the set.
+func (ks *KeySet) Contains(key []byte) bool {
+	for _, ref := range ks.index[hashKey(key)] {
+		if bytes.Equal(ks.arena.s[ref.off:ref.off+ref.n], key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add adds a copy of key to the set, and reports whether it was not already in the set.
+func (ks *KeySet) Add(key []byte) bool {
+	h := hashKey(key)
+	for _, ref := range ks.index[h] {
+		if bytes.Equal(ks.arena.s[ref.off:ref.off+ref.n], key) {
+			return false
+		}
+	}
+	if ks.index == nil {
+		ks.index = map[uint64][]keyRef{}
+	}
+	ks.index[h] = append(ks.index[h], keyRef{off: ks.arena.Len(), n: len(key)})
+	ks.arena.Append(key)
+	ks.n++
+	return true
+}
+
+// Reset removes all keys from the set, keeping the arena's capacity for re-use.
+func (ks *KeySet) Reset() {
+	ks.arena.Reset()
+	for h := range ks.index {
+		delete(ks.index, h)
+	}
+	ks.n = 0
+}
+
+// hashKey returns the 64-bit FNV-1a hash of key.
+func hashKey(key []byte) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for _, c := range key {
+		h ^= uint64(c)
+		h *= prime64
+	}
+	return h
+}
@@ -0,0 +1,25 @@
+package scratch
+
+import (
+	"testing"
+)
+
+func TestKeySet(t *testing.T) {
+	var ks KeySet
+	for _, k := range []string{"a", "b", "", "a", "b", "c"} {
+		ks.Add([]byte(k))
+	}
+	if n := ks.Len(); n != 4 {
+		t.Fatalf("Len() returns %d instead of %d", n, 4)
+	}
+	if ks.Add([]byte("c")) {
+		t.Fatal(`Add("c") reports a duplicate key as new`)
+	}
+	if !ks.Contains([]byte("")) || ks.Contains([]byte("d")) {
+		t.Fatal("Contains() does not report membership correctly")
+	}
+	ks.Reset()
+	if ks.Len() != 0 || ks.Contains([]byte("a")) || !ks.Add([]byte("a")) {
+		t.Fatal("Reset() does not empty the set")
+	}
+}
@@ -0,0 +1,62 @@
+package scratch
+
+import (
+	"io"
+)
+
+// AppendULEB128 appends the unsigned LEB128 encoding of x to the buffer, as used by WebAssembly and DWARF.
+// It's the same encoding as AppendUvarint. See Reader.ReadULEB128 for decoding.
+func (b *Buf) AppendULEB128(x uint64) *Buf {
+	return b.AppendUvarint(x)
+}
+
+// AppendLEB128 appends the signed LEB128 encoding of x to the buffer, as used by WebAssembly and DWARF.
+// Unlike AppendVarint, x is encoded in two's complement and sign-extended from the last byte, not zigzag-encoded,
+// and unlike AppendVarintRaw, small negative values are encoded in few bytes, e.g. -1 is encoded as 0x7f.
+// See Reader.ReadLEB128 for decoding.
+func (b *Buf) AppendLEB128(x int64) *Buf {
+	var a [10]byte
+	i := 0
+	for {
+		c := byte(x & 0x7f)
+		x >>= 7
+		if x == 0 && c&0x40 == 0 || x == -1 && c&0x40 != 0 {
+			a[i] = c
+			return b.Append(a[:i+1])
+		}
+		a[i] = c | 0x80
+		i++
+	}
+}
+
+// ReadULEB128 reads an unsigned LEB128-encoded uint64 as appended by Buf.AppendULEB128.
+// It returns ErrOverflow if the value overflows 64 bits.
+func (r *Reader) ReadULEB128() (uint64, error) {
+	return r.ReadUvarint()
+}
+
+// ReadLEB128 reads a signed LEB128-encoded int64 as appended by Buf.AppendLEB128.
+// It returns ErrOverflow if the value overflows 64 bits.
+func (r *Reader) ReadLEB128() (int64, error) {
+	s := r.s[r.off:]
+	if len(s) == 0 {
+		return 0, io.EOF
+	}
+	var x int64
+	for i, c := range s {
+		shift := uint(7 * i)
+		if shift == 63 && c != 0 && c != 0x7f {
+			// only the sign bit is left, so the byte must be a sign extension
+			return 0, ErrOverflow
+		}
+		x |= int64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			if shift += 7; shift < 64 && c&0x40 != 0 {
+				x |= -1 << shift
+			}
+			r.off += i + 1
+			return x, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
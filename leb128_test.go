@@ -0,0 +1,57 @@
+package scratch
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestLEB128(t *testing.T) {
+	tests := []struct {
+		x   int64
+		out []byte
+	}{
+		{0, []byte{0x00}},
+		{2, []byte{0x02}},
+		{-1, []byte{0x7f}},
+		{63, []byte{0x3f}},
+		{64, []byte{0xc0, 0x00}},
+		{-64, []byte{0x40}},
+		{-65, []byte{0xbf, 0x7f}},
+		{-123456, []byte{0xc0, 0xbb, 0x78}},
+		{math.MaxInt64, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}},
+		{math.MinInt64, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7f}},
+	}
+	for _, tt := range tests {
+		p := NewBuf(0).AppendLEB128(tt.x).Bytes()
+		if !bytes.Equal(p, tt.out) {
+			t.Fatalf("AppendLEB128(%d) results in %#v instead of %#v", tt.x, p, tt.out)
+		}
+		r := NewReader(p)
+		if x, err := r.ReadLEB128(); x != tt.x || err != nil || r.Remaining() != 0 {
+			t.Fatalf("ReadLEB128() of %#v returns (%d, %v) instead of (%d, nil)", p, x, err, tt.x)
+		}
+	}
+
+	if _, err := NewReader(nil).ReadLEB128(); err != io.EOF {
+		t.Fatalf("ReadLEB128() at the end returns error %v instead of %v", err, io.EOF)
+	}
+	if _, err := NewReader([]byte{0x80}).ReadLEB128(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ReadLEB128() of a truncated value returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	over := []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}
+	if _, err := NewReader(over).ReadLEB128(); err != ErrOverflow {
+		t.Fatalf("ReadLEB128() of an overflowing value returns error %v instead of %v", err, ErrOverflow)
+	}
+}
+
+func TestULEB128(t *testing.T) {
+	p := NewBuf(0).AppendULEB128(624485).Bytes()
+	if want := []byte{0xe5, 0x8e, 0x26}; !bytes.Equal(p, want) {
+		t.Fatalf("AppendULEB128(624485) results in %#v instead of %#v", p, want)
+	}
+	if x, err := NewReader(p).ReadULEB128(); x != 624485 || err != nil {
+		t.Fatalf("ReadULEB128() returns (%d, %v) instead of (624485, nil)", x, err)
+	}
+}
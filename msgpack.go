@@ -0,0 +1,68 @@
+package scratch
+
+import (
+	"math"
+)
+
+// AppendMsgpackInt appends i to the buffer as a MessagePack integer, using the smallest format that fits:
+// positive or negative fixint, or an uint8-64 or int8-64 format.
+func (b *Buf) AppendMsgpackInt(i int64) *Buf {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return b.AppendByte(byte(i))
+	case i < 0 && i >= -32:
+		return b.AppendByte(byte(i))
+	case i >= 0 && i <= math.MaxUint8:
+		return b.AppendByte(0xcc).AppendByte(byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		return b.AppendByte(0xcd).PutUint16(uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		return b.AppendByte(0xce).PutUint32(uint32(i))
+	case i >= 0:
+		return b.AppendByte(0xcf).PutUint64(uint64(i))
+	case i >= math.MinInt8:
+		return b.AppendByte(0xd0).AppendByte(byte(i))
+	case i >= math.MinInt16:
+		return b.AppendByte(0xd1).PutUint16(uint16(i))
+	case i >= math.MinInt32:
+		return b.AppendByte(0xd2).PutUint32(uint32(i))
+	default:
+		return b.AppendByte(0xd3).PutUint64(uint64(i))
+	}
+}
+
+// AppendMsgpackStr appends s to the buffer as a MessagePack string, using the fixstr or str8-32 format.
+// AppendMsgpackStr panics if s is longer than math.MaxUint32 bytes.
+func (b *Buf) AppendMsgpackStr(s string) *Buf {
+	n := len(s)
+	switch {
+	case n < 32:
+		b.AppendByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		b.AppendByte(0xd9).AppendByte(byte(n))
+	case n <= math.MaxUint16:
+		b.AppendByte(0xda).PutUint16(uint16(n))
+	case uint64(n) <= math.MaxUint32:
+		b.AppendByte(0xdb).PutUint32(uint32(n))
+	default:
+		panic("scratch.Buf.AppendMsgpackStr: string too long")
+	}
+	return b.AppendString(s)
+}
+
+// AppendMsgpackBin appends p to the buffer as MessagePack binary data, using the bin8-32 format.
+// AppendMsgpackBin panics if p is longer than math.MaxUint32 bytes.
+func (b *Buf) AppendMsgpackBin(p []byte) *Buf {
+	n := len(p)
+	switch {
+	case n <= math.MaxUint8:
+		b.AppendByte(0xc4).AppendByte(byte(n))
+	case n <= math.MaxUint16:
+		b.AppendByte(0xc5).PutUint16(uint16(n))
+	case uint64(n) <= math.MaxUint32:
+		b.AppendByte(0xc6).PutUint32(uint32(n))
+	default:
+		panic("scratch.Buf.AppendMsgpackBin: data too long")
+	}
+	return b.Append(p)
+}
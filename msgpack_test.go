@@ -0,0 +1,45 @@
+package scratch
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestAppendMsgpackInt(t *testing.T) {
+	tests := []struct {
+		i   int64
+		out []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{-1, []byte{0xff}},
+		{-32, []byte{0xe0}},
+		{128, []byte{0xcc, 0x80}},
+		{256, []byte{0xcd, 0x01, 0x00}},
+		{1 << 16, []byte{0xce, 0x00, 0x01, 0x00, 0x00}},
+		{1 << 32, []byte{0xcf, 0, 0, 0, 1, 0, 0, 0, 0}},
+		{-33, []byte{0xd0, 0xdf}},
+		{-129, []byte{0xd1, 0xff, 0x7f}},
+		{math.MinInt32, []byte{0xd2, 0x80, 0, 0, 0}},
+		{math.MinInt64, []byte{0xd3, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		if p := NewBuf(0).AppendMsgpackInt(tt.i).Bytes(); !bytes.Equal(p, tt.out) {
+			t.Fatalf("AppendMsgpackInt(%d) results in %#v instead of %#v", tt.i, p, tt.out)
+		}
+	}
+}
+
+func TestAppendMsgpackStrBin(t *testing.T) {
+	if p, want := NewBuf(0).AppendMsgpackStr("hi").Bytes(), []byte{0xa2, 'h', 'i'}; !bytes.Equal(p, want) {
+		t.Fatalf("AppendMsgpackStr(%q) results in %#v instead of %#v", "hi", p, want)
+	}
+	if p := NewBuf(0).AppendMsgpackStr(strings.Repeat("x", 32)).Bytes(); p[0] != 0xd9 || p[1] != 32 {
+		t.Fatalf("AppendMsgpackStr() of 32 bytes results in header %#v instead of %#v", p[:2], []byte{0xd9, 32})
+	}
+	if p, want := NewBuf(0).AppendMsgpackBin([]byte{1}).Bytes(), []byte{0xc4, 1, 1}; !bytes.Equal(p, want) {
+		t.Fatalf("AppendMsgpackBin() results in %#v instead of %#v", p, want)
+	}
+}
@@ -0,0 +1,20 @@
+package scratch
+
+import (
+	"net"
+)
+
+// AppendIP appends the on-wire form of ip to the buffer:
+// 4 bytes for IPv4 addresses, including IPv4-mapped IPv6 addresses, and 16 bytes for IPv6 addresses.
+// Nothing is appended if ip is not a valid IP address.
+func (b *Buf) AppendIP(ip net.IP) *Buf {
+	if p := ip.To4(); p != nil {
+		return b.Append(p)
+	}
+	return b.Append(ip.To16())
+}
+
+// AppendHardwareAddr appends the bytes of addr, e.g. a MAC address, to the buffer.
+func (b *Buf) AppendHardwareAddr(addr net.HardwareAddr) *Buf {
+	return b.Append(addr)
+}
@@ -0,0 +1,24 @@
+package scratch
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestAppendIP(t *testing.T) {
+	tests := []struct {
+		ip  net.IP
+		out []byte
+	}{
+		{net.ParseIP("192.0.2.1"), []byte{192, 0, 2, 1}},
+		{net.IPv4(192, 0, 2, 1).To16(), []byte{192, 0, 2, 1}},
+		{net.ParseIP("2001:db8::1"), []byte{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}},
+		{net.IP{1, 2}, nil},
+	}
+	for _, tt := range tests {
+		if p := NewBuf(0).AppendIP(tt.ip).Bytes(); !bytes.Equal(p, tt.out) {
+			t.Fatalf("AppendIP(%v) results in %#v instead of %#v", tt.ip, p, tt.out)
+		}
+	}
+}
@@ -1,17 +1,75 @@
 package scratch
 
 import (
+	"math"
 	"sync"
+	"sync/atomic"
 )
 
+// sizeDecay is the weight given to each new sample in the pool's trailing average of request sizes.
+const sizeDecay = 1.0 / 16
+
 // Pool is a wrapper around sync.Pool, holding Buf objects.
 type Pool struct {
-	p *sync.Pool
+	// these fields are accessed atomically, so they're first to ensure 64-bit alignment
+	highWater int64
+	avgSize   uint64 // float64 bits
+	maxCap    int64
+	shrinkCap int64
+	sampling  int32 // set by Cycle, see sampleSize
+
+	p        pooler
+	bufCap   int
+	adaptive bool
+}
+
+// PoolStats holds statistics about a Pool.
+type PoolStats struct {
+	// HighWater is the largest Buf.HighWater() of all buffers put into the pool.
+	HighWater int
+}
+
+// pooler is the subset of the sync.Pool API used by Pool.
+type pooler interface {
+	Get() interface{}
+	Put(x interface{})
+}
+
+// stackPool is a pooler that re-uses objects in LIFO order.
+type stackPool struct {
+	mu    sync.Mutex
+	New   func() interface{}
+	items []interface{}
+}
+
+func (p *stackPool) Get() interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(p.items)
+	if n == 0 {
+		return p.New()
+	}
+	x := p.items[n-1]
+	p.items[n-1] = nil
+	p.items = p.items[:n-1]
+	return x
+}
+
+func (p *stackPool) Put(x interface{}) {
+	p.mu.Lock()
+	p.items = append(p.items, x)
+	p.mu.Unlock()
 }
 
 // Get return a buffer from the pool.
 func (p *Pool) Get() *Buf {
-	return p.p.Get().(*Buf)
+	b := p.p.Get().(*Buf)
+	if max := atomic.LoadInt64(&p.maxCap); max > 0 && int64(b.Cap()) > max {
+		b = NewBuf(p.newCap())
+	}
+	b.pool = p
+	b.unpoison()
+	return b
 }
 
 // Put puts the buffer b into into the pool after resetting it.
@@ -19,15 +77,191 @@ func (p *Pool) Put(b *Buf) {
 	if b == nil {
 		return
 	}
+	b.pool = nil
+	for h := int64(b.HighWater()); ; {
+		old := atomic.LoadInt64(&p.highWater)
+		if h <= old || atomic.CompareAndSwapInt64(&p.highWater, old, h) {
+			break
+		}
+	}
+	if p.adaptive || atomic.LoadInt32(&p.sampling) != 0 {
+		p.sampleSize(b.Len())
+	}
+	c := int64(b.Cap())
+	over := false
+	if max := atomic.LoadInt64(&p.maxCap); max > 0 && c > max {
+		over = true
+	}
+	if shrink := atomic.LoadInt64(&p.shrinkCap); shrink > 0 && (c > shrink || over) {
+		b.s = make([]byte, 0, p.newCap())
+	} else if over {
+		return
+	}
 	b.Reset()
+	b.poison()
 	p.p.Put(b)
 }
 
+// sampleSize adds n to the trailing average of request sizes, with exponential decay.
+// As it contends for a word shared by all callers of Put, it's only called for pools that use the average:
+// adaptive pools, and pools on which Cycle was called.
+func (p *Pool) sampleSize(n int) {
+	for {
+		old := atomic.LoadUint64(&p.avgSize)
+		avg := math.Float64frombits(old)
+		if old == 0 {
+			avg = float64(n)
+		} else {
+			avg += (float64(n) - avg) * sizeDecay
+		}
+		if atomic.CompareAndSwapUint64(&p.avgSize, old, math.Float64bits(avg)) {
+			return
+		}
+	}
+}
+
+// newCap returns the capacity of new buffers: bufCap, or the trailing average of request sizes for adaptive pools.
+func (p *Pool) newCap() int {
+	if !p.adaptive {
+		return p.bufCap
+	}
+	avg := math.Float64frombits(atomic.LoadUint64(&p.avgSize))
+	if avg == 0 {
+		return p.bufCap
+	}
+	return int(math.Ceil(avg))
+}
+
+// SetShrinkCap makes Put re-allocate the storage of buffers whose capacity exceeds n back down to bufCap,
+// instead of pooling them as-is. Buffers exceeding the limit set by Cycle are also shrunk instead of being dropped.
+// This keeps the buffer pooled, avoiding an allocation on the next Get, while shedding the excess capacity.
+//
+// The default of 0 disables shrinking.
+func (p *Pool) SetShrinkCap(n int) {
+	atomic.StoreInt64(&p.shrinkCap, int64(n))
+}
+
+// Cycle sheds memory held by buffers that grew during a past peak in traffic.
+//
+// It sets the pool's capacity limit to twice the trailing average of request sizes, or bufCap if that's larger.
+// The request size average is taken from the length of buffers when they're put back into the pool, with exponential decay.
+// After a call to Cycle, buffers whose capacity exceeds the limit are dropped instead of being re-used.
+//
+// Cycle is intended to be called periodically, e.g. every few minutes, so the limit adapts to the traffic
+// without a fixed cap that hurts peak traffic.
+// Request sizes are only sampled from the first call to Cycle on, so by itself it doesn't set a limit.
+func (p *Pool) Cycle() {
+	if atomic.SwapInt32(&p.sampling, 1) == 0 && !p.adaptive {
+		return
+	}
+	max := int64(2 * math.Float64frombits(atomic.LoadUint64(&p.avgSize)))
+	if max < int64(p.bufCap) {
+		max = int64(p.bufCap)
+	}
+	atomic.StoreInt64(&p.maxCap, max)
+}
+
+// Stats returns statistics about the pool.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		HighWater: int(atomic.LoadInt64(&p.highWater)),
+	}
+}
+
+// Cap returns the capacity new buffers are initially sized with, as passed to NewPool.
+// For pools returned by NewAdaptivePool, it's the current estimate of the request size.
+func (p *Pool) Cap() int {
+	return p.newCap()
+}
+
+// With gets a buffer from the pool and calls f with it.
+// The buffer is put back into the pool when f returns, even if f panics,
+// so it must not be retained after f returns.
+func (p *Pool) With(f func(b *Buf)) {
+	b := p.Get()
+	defer p.putBack(b)
+	f(b)
+}
+
+// putBack puts b back into the pool, unless it was already put back, e.g. by Buf.Release.
+func (p *Pool) putBack(b *Buf) {
+	if b.pool == p {
+		p.Put(b)
+	}
+}
+
+// Acquire gets a buffer from the pool, and returns it along with a func that puts it back into the pool.
+// Only the first call to the func puts the buffer back, and only if it wasn't already put back, e.g. by Buf.Release,
+// so it's safe to call it more than once, e.g. in a defer statement and on an early return.
+// The func is not safe for concurrent use, and must not be called once the buffer was put back and re-used.
+//
+//	buf, release := pool.Acquire()
+//	defer release()
+func (p *Pool) Acquire() (*Buf, func()) {
+	b := p.Get()
+	done := false
+	return b, func() {
+		if done || b.pool != p {
+			return
+		}
+		done = true
+		p.Put(b)
+	}
+}
+
+// Warm pre-allocates n buffers and puts them into the pool.
+// It trades startup time for lower allocation latency on the first calls to Get.
+//
+// Like any other pooled buffer, they may be released by the garbage collector if left unused.
+func (p *Pool) Warm(n int) {
+	for i := 0; i < n; i++ {
+		p.p.Put(NewBuf(p.newCap()))
+	}
+}
+
 // NewPool returns a new pool of buffers initially sized with capacity bufCap.
 func NewPool(bufCap int) *Pool {
-	return &Pool{p: &sync.Pool{
+	return &Pool{
+		p: &sync.Pool{
+			New: func() interface{} {
+				return NewBuf(bufCap)
+			},
+		},
+		bufCap: bufCap,
+	}
+}
+
+// NewAdaptivePool returns a new pool of buffers that learns their initial capacity from use,
+// instead of relying on a hand-tuned capacity.
+//
+// New buffers are initially sized with capacity initialCap, until a buffer is put into the pool.
+// From then on they're sized with the trailing average of request sizes, as described by Cycle,
+// which is updated on every Put with exponential decay. The average is taken from the length of buffers put,
+// not their capacity, as the capacity of re-used buffers never shrinks and would only ever increase the estimate.
+func NewAdaptivePool(initialCap int) *Pool {
+	p := &Pool{
+		bufCap:   initialCap,
+		adaptive: true,
+	}
+	p.p = &sync.Pool{
 		New: func() interface{} {
-			return NewBuf(bufCap)
+			return NewBuf(p.newCap())
 		},
-	}}
+	}
+	return p
+}
+
+// NewDeterministicPool returns a new pool of buffers initially sized with capacity bufCap,
+// that re-uses buffers in a predictable LIFO order instead of using sync.Pool.
+//
+// Buffers are never released, so it's intended for tests where bugs involving buffer re-use must be reproducible.
+func NewDeterministicPool(bufCap int) *Pool {
+	return &Pool{
+		p: &stackPool{
+			New: func() interface{} {
+				return NewBuf(bufCap)
+			},
+		},
+		bufCap: bufCap,
+	}
 }
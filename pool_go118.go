@@ -0,0 +1,12 @@
+//go:build go1.18
+// +build go1.18
+
+package scratch
+
+// WithResult is like Pool.With, but returns the value computed by f.
+// The value must not reference the buffer's memory, e.g. via Bytes() or UnsafeString().
+func WithResult[T any](p *Pool, f func(b *Buf) T) T {
+	b := p.Get()
+	defer p.putBack(b)
+	return f(b)
+}
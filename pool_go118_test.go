@@ -0,0 +1,19 @@
+//go:build go1.18
+// +build go1.18
+
+package scratch
+
+import (
+	"testing"
+)
+
+func TestWithResultRelease(t *testing.T) {
+	pool := NewDeterministicPool(8)
+	n := WithResult(pool, func(b *Buf) int {
+		b.Release()
+		return 1
+	})
+	if b1, b2 := pool.Get(), pool.Get(); n != 1 || b1 == b2 {
+		t.Fatalf("calling Release() in WithResult() put the buffer into the pool twice")
+	}
+}
@@ -0,0 +1,161 @@
+package scratch
+
+import (
+	"testing"
+)
+
+func TestPoolWith(t *testing.T) {
+	pool := NewPool(8)
+	var got *Buf
+	func() {
+		defer func() { recover() }()
+		pool.With(func(b *Buf) {
+			got = b
+			b.AppendString("data")
+			panic("oops")
+		})
+	}()
+	if got.Len() != 0 {
+		t.Fatalf("With() did not put the buffer back after a panic, it has len %d", got.Len())
+	}
+}
+
+func TestPoolWithRelease(t *testing.T) {
+	pool := NewDeterministicPool(8)
+	pool.With(func(b *Buf) {
+		b.Release()
+	})
+	if b1, b2 := pool.Get(), pool.Get(); b1 == b2 {
+		t.Fatalf("calling Release() in With() put the buffer into the pool twice")
+	}
+}
+
+func TestDeterministicPool(t *testing.T) {
+	pool := NewDeterministicPool(8)
+	a, b := pool.Get(), pool.Get()
+	pool.Put(a)
+	pool.Put(b)
+	if pool.Get() != b || pool.Get() != a {
+		t.Fatal("NewDeterministicPool() does not re-use buffers in LIFO order")
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	pool := NewDeterministicPool(8)
+	b := pool.Get()
+	b.Grow(100)
+	pool.Put(b)
+	if h := pool.Stats().HighWater; h != 100 {
+		t.Fatalf("Stats().HighWater is %d instead of %d", h, 100)
+	}
+	if b = pool.Get(); b.HighWater() != 100 {
+		t.Fatalf("HighWater() after Reset() is %d instead of %d", b.HighWater(), 100)
+	}
+}
+
+func TestPoolCap(t *testing.T) {
+	if n := NewPool(64).Cap(); n != 64 {
+		t.Fatalf("Cap() returns %d instead of 64", n)
+	}
+}
+
+func TestAdaptivePool(t *testing.T) {
+	pool := NewAdaptivePool(8)
+	if n := pool.Cap(); n != 8 {
+		t.Fatalf("Cap() of a new pool returns %d instead of 8", n)
+	}
+	b := pool.Get()
+	b.Append(make([]byte, 100))
+	pool.Put(b)
+	if n := pool.Cap(); n != 100 {
+		t.Fatalf("Cap() after a request of 100 bytes returns %d instead of 100", n)
+	}
+	for i := 0; i < 100; i++ {
+		b := pool.Get()
+		b.Append(make([]byte, 20))
+		pool.Put(b)
+	}
+	if n := pool.Cap(); n < 20 || n > 25 {
+		t.Fatalf("Cap() after many requests of 20 bytes returns %d", n)
+	}
+	if b := pool.Get(); b.Cap() < 20 {
+		t.Fatalf("Get() returns a buffer with capacity %d instead of at least 20", b.Cap())
+	}
+}
+
+func TestPoolCycle(t *testing.T) {
+	pool := NewDeterministicPool(8)
+	pool.Cycle()
+	if b := pool.Get(); b.Cap() != 8 {
+		t.Fatalf("Get() after the first Cycle() returns a buffer with cap %d instead of 8", b.Cap())
+	}
+	big := pool.Get()
+	big.Tail(1000)
+	pool.Put(big)
+	for i := 0; i < 200; i++ {
+		b := pool.Get()
+		b.Tail(8)
+		pool.Put(b)
+	}
+	pool.Cycle()
+	if b := pool.Get(); b == big || b.Cap() > 32 {
+		t.Fatalf("Get() after Cycle() returns a buffer with cap %d", b.Cap())
+	}
+}
+
+func TestRelease(t *testing.T) {
+	pool := NewDeterministicPool(8)
+	b := pool.Get()
+	b.AppendString("data")
+	b.Release()
+	b.Release()
+	if pool.Get() != b {
+		t.Fatal("Release() did not put the buffer back into its pool")
+	}
+	if pool.Get() == b {
+		t.Fatal("Release() put the buffer back into its pool twice")
+	}
+	NewBuf(0).Release()
+}
+
+func TestPoolShrinkCap(t *testing.T) {
+	pool := NewDeterministicPool(8)
+	pool.SetShrinkCap(64)
+	b := pool.Get()
+	b.Grow(100)
+	pool.Put(b)
+	if b2 := pool.Get(); b2 != b || b2.Cap() != 8 {
+		t.Fatalf("Get() after Put() of an oversized buffer returns a buffer with cap %d instead of the same buffer with cap %d", b2.Cap(), 8)
+	}
+}
+
+func TestPoolAcquire(t *testing.T) {
+	pool := NewDeterministicPool(8)
+	b, release := pool.Acquire()
+	b.AppendString("data")
+	release()
+	if b.Len() != 0 {
+		t.Fatalf("release() did not put the buffer back, it has len %d", b.Len())
+	}
+	release()
+	if b1, b2 := pool.Get(), pool.Get(); b1 == b2 {
+		t.Fatalf("calling release() twice put the buffer into the pool twice")
+	}
+}
+
+func TestPoolAcquireRelease(t *testing.T) {
+	pool := NewDeterministicPool(8)
+	b, release := pool.Acquire()
+	b.Release()
+	release()
+	if b1, b2 := pool.Get(), pool.Get(); b1 == b2 {
+		t.Fatalf("calling Release() then release() put the buffer into the pool twice")
+	}
+}
+
+func BenchmarkPoolGetPut(b *testing.B) {
+	pool := NewPool(64)
+	for i := 0; i < b.N; i++ {
+		pool.Put(pool.Get().AppendByte(1))
+	}
+}
@@ -0,0 +1,222 @@
+package scratch
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrInvalidField is returned when decoding a protobuf field with an invalid field number or wire type.
+var ErrInvalidField = errors.New("scratch: invalid protobuf field")
+
+// Protobuf wire types, as used by AppendTag.
+const (
+	WireVarint     = 0
+	WireFixed64    = 1
+	WireBytes      = 2
+	WireStartGroup = 3
+	WireEndGroup   = 4
+	WireFixed32    = 5
+)
+
+// Protobuf field number limits.
+const (
+	MinFieldNumber           = 1
+	MaxFieldNumber           = 1<<29 - 1
+	FirstReservedFieldNumber = 19000
+	LastReservedFieldNumber  = 19999
+)
+
+// maxGroupDepth is the maximum nesting depth of groups skipped by Reader.SkipField, like protobuf's default.
+const maxGroupDepth = 100
+
+// AppendTag appends the protobuf key of a field, i.e. the varint fieldNum<<3 | wireType.
+//
+// AppendTag panics if fieldNum is outside the valid range, or in the range reserved for the protobuf implementation,
+// or if wireType is not a valid wire type.
+func (b *Buf) AppendTag(fieldNum, wireType int) *Buf {
+	if fieldNum < MinFieldNumber || fieldNum > MaxFieldNumber ||
+		fieldNum >= FirstReservedFieldNumber && fieldNum <= LastReservedFieldNumber {
+		panic("scratch.Buf.AppendTag: invalid field number")
+	}
+	if wireType < WireVarint || wireType > WireFixed32 {
+		panic("scratch.Buf.AppendTag: invalid wire type")
+	}
+	return b.AppendUvarint(uint64(fieldNum)<<3 | uint64(wireType))
+}
+
+// AppendFieldVarint appends a protobuf varint field, e.g. uint64, int64 or bool.
+func (b *Buf) AppendFieldVarint(fieldNum int, v uint64) *Buf {
+	return b.AppendTag(fieldNum, WireVarint).AppendUvarint(v)
+}
+
+// AppendFieldBytes appends a length-delimited protobuf field, e.g. bytes or an embedded message.
+func (b *Buf) AppendFieldBytes(fieldNum int, p []byte) *Buf {
+	return b.AppendTag(fieldNum, WireBytes).AppendLenPrefixed(p)
+}
+
+// AppendEmbeddedMessage appends an already-marshaled protobuf message raw as an embedded message field,
+// without decoding or re-marshaling it.
+func (b *Buf) AppendEmbeddedMessage(fieldNum int, raw []byte) *Buf {
+	return b.AppendFieldBytes(fieldNum, raw)
+}
+
+// AppendFieldString appends a length-delimited protobuf string field.
+func (b *Buf) AppendFieldString(fieldNum int, s string) *Buf {
+	return b.AppendTag(fieldNum, WireBytes).AppendUvarint(uint64(len(s))).AppendString(s)
+}
+
+// AppendFieldFixed32 appends a protobuf fixed32 field, in little-endian order, e.g. fixed32 or float.
+func (b *Buf) AppendFieldFixed32(fieldNum int, v uint32) *Buf {
+	b.AppendTag(fieldNum, WireFixed32)
+	binary.LittleEndian.PutUint32(b.Tail(4), v)
+	return b
+}
+
+// AppendFieldFixed64 appends a protobuf fixed64 field, in little-endian order, e.g. fixed64 or double.
+func (b *Buf) AppendFieldFixed64(fieldNum int, v uint64) *Buf {
+	b.AppendTag(fieldNum, WireFixed64)
+	binary.LittleEndian.PutUint64(b.Tail(8), v)
+	return b
+}
+
+// AppendPackedUint32 appends a packed repeated protobuf field of varint-encoded values, e.g. repeated uint32.
+// Like protobuf, nothing is appended if vs is empty.
+func (b *Buf) AppendPackedUint32(fieldNum int, vs []uint32) *Buf {
+	if len(vs) == 0 {
+		return b
+	}
+	n := 0
+	for _, v := range vs {
+		n += uvarintLen(uint64(v))
+	}
+	b.AppendTag(fieldNum, WireBytes).AppendUvarint(uint64(n)).Grow(n)
+	for _, v := range vs {
+		b.AppendUvarint(uint64(v))
+	}
+	return b
+}
+
+// AppendPackedFixed32 appends a packed repeated protobuf field of fixed32 values, e.g. repeated fixed32 or float.
+// Like protobuf, nothing is appended if vs is empty.
+func (b *Buf) AppendPackedFixed32(fieldNum int, vs []uint32) *Buf {
+	if len(vs) == 0 {
+		return b
+	}
+	b.AppendTag(fieldNum, WireBytes).AppendUvarint(uint64(4 * len(vs)))
+	s := b.Tail(4 * len(vs))
+	for i, v := range vs {
+		binary.LittleEndian.PutUint32(s[4*i:], v)
+	}
+	return b
+}
+
+// AppendPackedFixed64 appends a packed repeated protobuf field of fixed64 values, e.g. repeated fixed64 or double.
+// Like protobuf, nothing is appended if vs is empty.
+func (b *Buf) AppendPackedFixed64(fieldNum int, vs []uint64) *Buf {
+	if len(vs) == 0 {
+		return b
+	}
+	b.AppendTag(fieldNum, WireBytes).AppendUvarint(uint64(8 * len(vs)))
+	s := b.Tail(8 * len(vs))
+	for i, v := range vs {
+		binary.LittleEndian.PutUint64(s[8*i:], v)
+	}
+	return b
+}
+
+// uvarintLen returns the number of bytes needed to varint-encode v.
+func uvarintLen(v uint64) int {
+	n := 1
+	for ; v >= 0x80; v >>= 7 {
+		n++
+	}
+	return n
+}
+
+// NextField reads the key of the next protobuf field and returns its field number and wire type.
+// The field's value must then be read with the method matching its wire type, or skipped with SkipField.
+// It returns ErrInvalidField if the field number or wire type is invalid.
+func (r *Reader) NextField() (fieldNum, wireType int, err error) {
+	key, err := r.ReadUvarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	num, typ := key>>3, int(key&7)
+	if num < MinFieldNumber || num > MaxFieldNumber || typ > WireFixed32 {
+		return 0, 0, ErrInvalidField
+	}
+	r.field = int(num)
+	return int(num), typ, nil
+}
+
+// ReadFixed32 reads a little-endian uint32, e.g. the value of a protobuf fixed32 field.
+func (r *Reader) ReadFixed32() (uint32, error) {
+	s, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(s), nil
+}
+
+// ReadFixed64 reads a little-endian uint64, e.g. the value of a protobuf fixed64 field.
+func (r *Reader) ReadFixed64() (uint64, error) {
+	s, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(s), nil
+}
+
+// SkipField skips the value of a protobuf field with the given wire type, as returned by NextField,
+// which must be the last key read.
+// Groups are skipped up to and including their matching end group.
+// It returns ErrInvalidField if an end group doesn't match its start group, or if groups are nested too deeply.
+func (r *Reader) SkipField(wireType int) error {
+	return r.skipField(wireType, 0)
+}
+
+// skipField is like SkipField, for a field nested in depth groups.
+func (r *Reader) skipField(wireType, depth int) error {
+	var err error
+	switch wireType {
+	case WireVarint:
+		_, err = r.ReadUvarint()
+	case WireFixed64:
+		_, err = r.next(8)
+	case WireBytes:
+		_, err = r.ReadLenPrefixed()
+	case WireFixed32:
+		_, err = r.next(4)
+	case WireStartGroup:
+		err = r.skipGroup(r.field, depth+1)
+	default:
+		err = ErrInvalidField
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// skipGroup skips the fields of the group fieldNum, nested in depth groups, up to and including its end group.
+func (r *Reader) skipGroup(fieldNum, depth int) error {
+	if depth > maxGroupDepth {
+		return ErrInvalidField
+	}
+	for {
+		num, typ, err := r.NextField()
+		if err != nil {
+			return err
+		}
+		if typ == WireEndGroup {
+			if num != fieldNum {
+				return ErrInvalidField
+			}
+			return nil
+		}
+		if err := r.skipField(typ, depth); err != nil {
+			return err
+		}
+	}
+}
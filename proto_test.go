@@ -0,0 +1,128 @@
+package scratch
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestAppendField(t *testing.T) {
+	sb := NewBuf(0).
+		AppendFieldVarint(1, 150).
+		AppendFieldString(2, "testing").
+		AppendFieldFixed32(3, 1).
+		AppendFieldFixed64(16, 1)
+	want := []byte{
+		0x08, 0x96, 0x01,
+		0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g',
+		0x1d, 0x01, 0x00, 0x00, 0x00,
+		0x81, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	if p := sb.Bytes(); !bytes.Equal(p, want) {
+		t.Fatalf("AppendField*() results in %#v instead of %#v", p, want)
+	}
+}
+
+func TestAppendEmbeddedMessage(t *testing.T) {
+	inner := NewBuf(0).AppendFieldVarint(1, 150).Bytes()
+	sb := NewBuf(0).AppendEmbeddedMessage(3, inner)
+	want := []byte{0x1a, 0x03, 0x08, 0x96, 0x01}
+	if p := sb.Bytes(); !bytes.Equal(p, want) {
+		t.Fatalf("AppendEmbeddedMessage() results in %#v instead of %#v", p, want)
+	}
+}
+
+func TestAppendTagInvalid(t *testing.T) {
+	for _, num := range []int{0, 19000, 19999, MaxFieldNumber + 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("AppendTag(%d, WireVarint) did not panic", num)
+				}
+			}()
+			NewBuf(0).AppendTag(num, WireVarint)
+		}()
+	}
+}
+
+func TestNextField(t *testing.T) {
+	sb := NewBuf(0).
+		AppendFieldVarint(1, 150).
+		AppendFieldString(2, "skipped").
+		AppendTag(3, WireStartGroup).AppendFieldFixed32(1, 7).AppendTag(3, WireEndGroup).
+		AppendFieldFixed64(4, 42).
+		AppendFieldFixed32(5, 9)
+	r := NewReader(sb.Bytes())
+	var got []uint64
+	for {
+		num, typ, err := r.NextField()
+		if err != nil {
+			break
+		}
+		switch num {
+		case 1:
+			v, _ := r.ReadUvarint()
+			got = append(got, v)
+		case 4:
+			v, _ := r.ReadFixed64()
+			got = append(got, v)
+		case 5:
+			v, _ := r.ReadFixed32()
+			got = append(got, uint64(v))
+		default:
+			if err := r.SkipField(typ); err != nil {
+				t.Fatalf("SkipField(%d) for field %d returns error %v", typ, num, err)
+			}
+		}
+	}
+	if len(got) != 3 || got[0] != 150 || got[1] != 42 || got[2] != 9 {
+		t.Fatalf("NextField() loop reads values %v instead of %v", got, []uint64{150, 42, 9})
+	}
+	if _, _, err := NewReader([]byte{0x07}).NextField(); err != ErrInvalidField {
+		t.Fatalf("NextField() of an invalid key returns error %v instead of %v", err, ErrInvalidField)
+	}
+}
+
+func TestSkipGroup(t *testing.T) {
+	nested := func(depth int) []byte {
+		sb := NewBuf(0)
+		for i := 0; i < depth; i++ {
+			sb.AppendTag(1, WireStartGroup)
+		}
+		for i := 0; i < depth; i++ {
+			sb.AppendTag(1, WireEndGroup)
+		}
+		return sb.Bytes()
+	}
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{nested(maxGroupDepth), nil},
+		{nested(maxGroupDepth + 1), ErrInvalidField},
+		{bytes.Repeat([]byte{0x0b}, 1<<20), ErrInvalidField},
+		{NewBuf(0).AppendTag(1, WireStartGroup).AppendTag(2, WireEndGroup).Bytes(), ErrInvalidField},
+		{NewBuf(0).AppendTag(1, WireStartGroup).Bytes(), io.ErrUnexpectedEOF},
+	}
+	for i, tt := range tests {
+		r := NewReader(tt.data)
+		_, typ, _ := r.NextField()
+		if err := r.SkipField(typ); err != tt.err {
+			t.Fatalf("SkipField() of test %d returns error %v instead of %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestAppendPacked(t *testing.T) {
+	sb := NewBuf(0).
+		AppendPackedUint32(4, []uint32{3, 270, 86942}).
+		AppendPackedFixed32(5, []uint32{1}).
+		AppendPackedFixed64(6, nil)
+	want := []byte{
+		0x22, 0x06, 0x03, 0x8e, 0x02, 0x9e, 0xa7, 0x05,
+		0x2a, 0x04, 0x01, 0x00, 0x00, 0x00,
+	}
+	if p := sb.Bytes(); !bytes.Equal(p, want) {
+		t.Fatalf("AppendPacked*() results in %#v instead of %#v", p, want)
+	}
+}
@@ -0,0 +1,249 @@
+package scratch
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// ErrOverflow is returned when decoding a varint that overflows 64 bits.
+var ErrOverflow = errors.New("scratch: varint overflows a 64-bit integer")
+
+// ErrInvalidBool is returned when decoding a bool from a byte other than 0 or 1.
+var ErrInvalidBool = errors.New("scratch: invalid bool")
+
+// ErrOffset is returned when moving a Reader's offset outside of its slice.
+var ErrOffset = errors.New("scratch: offset out of range")
+
+// Reader decodes values appended by Buf methods like AppendUvarint from a byte slice.
+//
+// Methods return io.EOF if there are no unread bytes,
+// or io.ErrUnexpectedEOF if the value is truncated.
+type Reader struct {
+	s     []byte
+	off   int
+	field int // field number of the last protobuf key read by NextField
+}
+
+// Remaining returns the number of unread bytes.
+func (r *Reader) Remaining() int {
+	return len(r.s) - r.off
+}
+
+// Pos returns the offset of the next unread byte.
+func (r *Reader) Pos() int {
+	return r.off
+}
+
+// Rewind moves the offset back by n bytes, so they're read again, e.g. after looking ahead.
+// It returns ErrOffset, and leaves the offset unchanged, if n is negative or greater than Pos().
+func (r *Reader) Rewind(n int) error {
+	if n < 0 || n > r.off {
+		return ErrOffset
+	}
+	r.off -= n
+	return nil
+}
+
+// Seek implements io.Seeker, setting the offset of the next unread byte.
+// It returns ErrOffset, and leaves the offset unchanged, if the new offset is outside of the slice.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += int64(r.off)
+	case io.SeekEnd:
+		offset += int64(len(r.s))
+	default:
+		return 0, errors.New("scratch.Reader.Seek: invalid whence")
+	}
+	if offset < 0 || offset > int64(len(r.s)) {
+		return 0, ErrOffset
+	}
+	r.off = int(offset)
+	return offset, nil
+}
+
+// next consumes the next n bytes.
+func (r *Reader) next(n int) ([]byte, error) {
+	switch l := r.Remaining(); {
+	case l == 0 && n != 0:
+		return nil, io.EOF
+	case l < n:
+		return nil, io.ErrUnexpectedEOF
+	}
+	s := r.s[r.off : r.off+n]
+	r.off += n
+	return s, nil
+}
+
+// ReadUint64 reads a big-endian uint64 as appended by Buf.PutUint64.
+func (r *Reader) ReadUint64() (uint64, error) {
+	s, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(s), nil
+}
+
+// ReadUint32 reads a big-endian uint32 as appended by Buf.PutUint32.
+func (r *Reader) ReadUint32() (uint32, error) {
+	s, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(s), nil
+}
+
+// ReadUint16 reads a big-endian uint16 as appended by Buf.PutUint16.
+func (r *Reader) ReadUint16() (uint16, error) {
+	s, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(s), nil
+}
+
+// ReadBool reads a bool as appended by Buf.PutBool.
+// It's strict, returning ErrInvalidBool without consuming the byte if it's neither 0 nor 1,
+// which helps catch corrupt data. See ReadBoolLenient to treat any non-zero byte as true.
+func (r *Reader) ReadBool() (bool, error) {
+	s, err := r.next(1)
+	if err != nil {
+		return false, err
+	}
+	if s[0] > 1 {
+		r.off--
+		return false, ErrInvalidBool
+	}
+	return s[0] == 1, nil
+}
+
+// ReadBoolLenient reads a bool as appended by Buf.PutBool, treating any non-zero byte as true, like C.
+func (r *Reader) ReadBoolLenient() (bool, error) {
+	s, err := r.next(1)
+	if err != nil {
+		return false, err
+	}
+	return s[0] != 0, nil
+}
+
+// ReadOrderedInt64 reads an int64 as appended by Buf.PutOrderedInt64.
+func (r *Reader) ReadOrderedInt64() (int64, error) {
+	n, err := r.ReadUint64()
+	if err != nil {
+		return 0, err
+	}
+	return int64(n ^ 1<<63), nil
+}
+
+// ReadOrderedFloat64 reads a float64 as appended by Buf.PutOrderedFloat64.
+func (r *Reader) ReadOrderedFloat64() (float64, error) {
+	n, err := r.ReadUint64()
+	if err != nil {
+		return 0, err
+	}
+	if n&(1<<63) != 0 {
+		n ^= 1 << 63
+	} else {
+		n = ^n
+	}
+	return math.Float64frombits(n), nil
+}
+
+// ReadUvarint reads a varint-encoded uint64 as appended by Buf.AppendUvarint.
+// It returns ErrOverflow if the varint overflows 64 bits.
+func (r *Reader) ReadUvarint() (uint64, error) {
+	if r.off == len(r.s) {
+		return 0, io.EOF
+	}
+	n, i := binary.Uvarint(r.s[r.off:])
+	switch {
+	case i == 0:
+		return 0, io.ErrUnexpectedEOF
+	case i < 0:
+		return 0, ErrOverflow
+	}
+	r.off += i
+	return n, nil
+}
+
+// ReadVarint reads a zigzag varint-encoded int64 as appended by Buf.AppendVarint.
+// It returns ErrOverflow if the varint overflows 64 bits.
+func (r *Reader) ReadVarint() (int64, error) {
+	n, err := r.ReadUvarint()
+	if err != nil {
+		return 0, err
+	}
+	return int64(n>>1) ^ -int64(n&1), nil
+}
+
+// ReadVarintRaw reads a two's complement varint-encoded int64 as appended by Buf.AppendVarintRaw.
+// It returns ErrOverflow if the varint overflows 64 bits.
+func (r *Reader) ReadVarintRaw() (int64, error) {
+	n, err := r.ReadUvarint()
+	return int64(n), err
+}
+
+// ReadLenPrefixed reads a varint length-prefixed byte slice as appended by Buf.AppendLenPrefixed,
+// e.g. the value of a length-delimited protobuf field.
+//
+// The result is a sub-slice of the Reader's underlying slice, not a copy.
+func (r *Reader) ReadLenPrefixed() ([]byte, error) {
+	n, err := r.ReadUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Remaining()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return r.next(int(n))
+}
+
+// ReadBlobList reads a list of byte slices as appended by Buf.AppendBlobList.
+//
+// The items are sub-slices of the Reader's underlying slice, not copies.
+func (r *Reader) ReadBlobList() ([][]byte, error) {
+	n, err := r.ReadUvarint()
+	if err != nil {
+		return nil, err
+	}
+	// each item takes at least 1 byte, so don't trust counts that can't fit
+	if n > uint64(r.Remaining()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	items := make([][]byte, n)
+	for i := range items {
+		if items[i], err = r.ReadLenPrefixed(); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// Limited returns a new Reader over the next n bytes, and advances r past them.
+// It's useful for parsing length-delimited values, like embedded protobuf messages, without over-reading.
+//
+// Like io.LimitReader, if fewer than n bytes remain, the new Reader is limited to the remaining bytes.
+// Limited panics if n is negative.
+func (r *Reader) Limited(n int) *Reader {
+	if n < 0 {
+		panic("scratch.Reader.Limited: negative count")
+	}
+	if l := r.Remaining(); n > l {
+		n = l
+	}
+	s := r.s[r.off : r.off+n : r.off+n]
+	r.off += n
+	return NewReader(s)
+}
+
+// NewReader returns a new Reader reading from s.
+// The Reader does not copy s, so s must not be modified while it's in use.
+func NewReader(s []byte) *Reader {
+	return &Reader{s: s}
+}
@@ -0,0 +1,184 @@
+package scratch
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestReadUvarint(t *testing.T) {
+	ns := []uint64{0, 1, 127, 128, 300, math.MaxUint32, 1<<63 - 1, math.MaxUint64}
+	sb := NewBuf(0)
+	for _, n := range ns {
+		sb.AppendUvarint(n)
+	}
+	r := NewReader(sb.Bytes())
+	for _, want := range ns {
+		if n, err := r.ReadUvarint(); n != want || err != nil {
+			t.Fatalf("ReadUvarint() returns (%d, %v) instead of (%d, nil)", n, err, want)
+		}
+	}
+	if _, err := r.ReadUvarint(); err != io.EOF {
+		t.Fatalf("ReadUvarint() at the end returns error %v instead of %v", err, io.EOF)
+	}
+	if _, err := NewReader([]byte{0x80, 0x80}).ReadUvarint(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ReadUvarint() of a truncated varint returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	overflow := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02}
+	if _, err := NewReader(overflow).ReadUvarint(); err != ErrOverflow {
+		t.Fatalf("ReadUvarint() of an overflowing varint returns error %v instead of %v", err, ErrOverflow)
+	}
+}
+
+func TestOrderedInt64(t *testing.T) {
+	ns := []int64{math.MinInt64, -1 << 40, -1, 0, 1, 1 << 40, math.MaxInt64}
+	var prev []byte
+	for _, n := range ns {
+		key := NewBuf(0).PutOrderedInt64(n).Bytes()
+		if prev != nil && bytes.Compare(prev, key) >= 0 {
+			t.Fatalf("PutOrderedInt64(%d) results in %#v, which does not sort after %#v", n, key, prev)
+		}
+		prev = key
+		if m, err := NewReader(key).ReadOrderedInt64(); m != n || err != nil {
+			t.Fatalf("ReadOrderedInt64() returns (%d, %v) instead of (%d, nil)", m, err, n)
+		}
+	}
+}
+
+func TestOrderedFloat64(t *testing.T) {
+	fs := []float64{math.Inf(-1), -math.MaxFloat64, -1.5, -math.SmallestNonzeroFloat64, 0, math.SmallestNonzeroFloat64, 1.5, math.MaxFloat64, math.Inf(1)}
+	var prev []byte
+	for _, f := range fs {
+		key := NewBuf(0).PutOrderedFloat64(f).Bytes()
+		if prev != nil && bytes.Compare(prev, key) >= 0 {
+			t.Fatalf("PutOrderedFloat64(%g) results in %#v, which does not sort after %#v", f, key, prev)
+		}
+		prev = key
+		if g, err := NewReader(key).ReadOrderedFloat64(); g != f || err != nil {
+			t.Fatalf("ReadOrderedFloat64() returns (%g, %v) instead of (%g, nil)", g, err, f)
+		}
+	}
+}
+
+func TestLimited(t *testing.T) {
+	r := NewReader(NewBuf(0).PutUint16(1).PutUint16(2).PutUint16(3).Bytes())
+	sub := r.Limited(4)
+	if n, err := sub.ReadUint32(); n != 1<<16|2 || err != nil {
+		t.Fatalf("Limited(4).ReadUint32() returns (%d, %v) instead of (%d, nil)", n, err, 1<<16|2)
+	}
+	if _, err := sub.ReadUint16(); err != io.EOF {
+		t.Fatalf("reading past the limit returns error %v instead of %v", err, io.EOF)
+	}
+	if n, err := r.ReadUint16(); n != 3 || err != nil {
+		t.Fatalf("ReadUint16() after Limited(4) returns (%d, %v) instead of (3, nil)", n, err)
+	}
+}
+
+func TestReadVarint(t *testing.T) {
+	ns := []int64{0, 1, -1, 63, -64, math.MaxInt64, math.MinInt64}
+	for _, n := range ns {
+		zz := NewBuf(0).AppendVarint(n)
+		if m, err := NewReader(zz.Bytes()).ReadVarint(); m != n || err != nil {
+			t.Fatalf("ReadVarint() returns (%d, %v) instead of (%d, nil)", m, err, n)
+		}
+		raw := NewBuf(0).AppendVarintRaw(n)
+		if m, err := NewReader(raw.Bytes()).ReadVarintRaw(); m != n || err != nil {
+			t.Fatalf("ReadVarintRaw() returns (%d, %v) instead of (%d, nil)", m, err, n)
+		}
+		if n < 0 && raw.Len() != 10 {
+			t.Fatalf("AppendVarintRaw(%d) results in %d bytes instead of 10", n, raw.Len())
+		}
+	}
+	if n := NewBuf(0).AppendVarint(-1).Len(); n != 1 {
+		t.Fatalf("AppendVarint(-1) results in %d bytes instead of 1", n)
+	}
+}
+
+func TestReadBlobList(t *testing.T) {
+	items := [][]byte{[]byte("a"), {}, []byte("bcd")}
+	sb := NewBuf(0).AppendBlobList(items).AppendBlobList(nil)
+	r := NewReader(sb.Bytes())
+	got, err := r.ReadBlobList()
+	if err != nil || len(got) != len(items) {
+		t.Fatalf("ReadBlobList() returns (%q, %v) instead of (%q, nil)", got, err, items)
+	}
+	for i := range items {
+		if !bytes.Equal(got[i], items[i]) {
+			t.Fatalf("ReadBlobList() item %d is %q instead of %q", i, got[i], items[i])
+		}
+	}
+	if got, err := r.ReadBlobList(); len(got) != 0 || err != nil {
+		t.Fatalf("ReadBlobList() of an empty list returns (%q, %v) instead of ([], nil)", got, err)
+	}
+	if _, err := NewReader([]byte{2, 1, 'a'}).ReadBlobList(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ReadBlobList() of a truncated list returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestReaderPos(t *testing.T) {
+	r := NewReader(make([]byte, 10))
+	r.ReadUint16()
+	if r.Pos() != 2 || r.Remaining() != 8 {
+		t.Fatalf("Pos() and Remaining() return (%d, %d) instead of (2, 8)", r.Pos(), r.Remaining())
+	}
+}
+
+func TestReaderRewind(t *testing.T) {
+	r := NewReader([]byte{1, 2, 3})
+	v1, _ := r.ReadUint16()
+	if err := r.Rewind(2); err != nil {
+		t.Fatalf("Rewind(2) returns error %v", err)
+	}
+	if v2, _ := r.ReadUint16(); v1 != v2 {
+		t.Fatalf("ReadUint16() after Rewind(2) returns %#x instead of %#x", v2, v1)
+	}
+	if err := r.Rewind(3); err != ErrOffset || r.Pos() != 2 {
+		t.Fatalf("Rewind(3) at offset 2 returns error %v and moves to %d", err, r.Pos())
+	}
+}
+
+func TestReaderSeek(t *testing.T) {
+	var _ io.Seeker = (*Reader)(nil)
+	r := NewReader([]byte{1, 2, 3})
+	tests := []struct {
+		offset int64
+		whence int
+		pos    int64
+		err    error
+	}{
+		{1, io.SeekStart, 1, nil},
+		{1, io.SeekCurrent, 2, nil},
+		{-3, io.SeekEnd, 0, nil},
+		{0, io.SeekEnd, 3, nil},
+		{1, io.SeekCurrent, 0, ErrOffset},
+		{-1, io.SeekStart, 0, ErrOffset},
+	}
+	for _, tt := range tests {
+		if pos, err := r.Seek(tt.offset, tt.whence); pos != tt.pos || err != tt.err {
+			t.Fatalf("Seek(%d, %d) returns (%d, %v) instead of (%d, %v)", tt.offset, tt.whence, pos, err, tt.pos, tt.err)
+		}
+	}
+	if r.Pos() != 3 {
+		t.Fatalf("failed Seek() calls move the offset to %d", r.Pos())
+	}
+}
+
+func TestReadBool(t *testing.T) {
+	data := NewBuf(0).PutBool(true).PutBool(false).AppendByte(2).Bytes()
+	r := NewReader(data)
+	for _, want := range []bool{true, false} {
+		if v, err := r.ReadBool(); v != want || err != nil {
+			t.Fatalf("ReadBool() returns (%v, %v) instead of (%v, nil)", v, err, want)
+		}
+	}
+	if _, err := r.ReadBool(); err != ErrInvalidBool {
+		t.Fatalf("ReadBool() of 2 returns error %v instead of %v", err, ErrInvalidBool)
+	}
+	if v, err := r.ReadBoolLenient(); !v || err != nil {
+		t.Fatalf("ReadBoolLenient() of 2 returns (%v, %v) instead of (true, nil)", v, err)
+	}
+	if _, err := r.ReadBool(); err != io.EOF {
+		t.Fatalf("ReadBool() at the end returns error %v instead of %v", err, io.EOF)
+	}
+}
@@ -0,0 +1,42 @@
+package scratch
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrInvalidRLE is returned when decoding a run-length encoding with a zero count.
+var ErrInvalidRLE = errors.New("scratch: invalid run-length encoding")
+
+// AppendRLE appends the run-length encoding of src to the buffer.
+// Each run of identical bytes is encoded as a [count][byte] pair, with runs longer than 255 bytes split into several pairs.
+// See Reader.DecodeRLE for decoding.
+func (b *Buf) AppendRLE(src []byte) *Buf {
+	for len(src) != 0 {
+		c := src[0]
+		n := 1
+		for n < len(src) && n < 255 && src[n] == c {
+			n++
+		}
+		b.AppendByte(byte(n)).AppendByte(c)
+		src = src[n:]
+	}
+	return b
+}
+
+// DecodeRLE decodes all remaining bytes as a run-length encoding appended by Buf.AppendRLE, and appends the result to dst.
+// It returns ErrInvalidRLE if a pair has a zero count, or io.ErrUnexpectedEOF if the last pair is truncated.
+func (r *Reader) DecodeRLE(dst *Buf) error {
+	for r.off < len(r.s) {
+		if r.Remaining() < 2 {
+			return io.ErrUnexpectedEOF
+		}
+		n, c := int(r.s[r.off]), r.s[r.off+1]
+		if n == 0 {
+			return ErrInvalidRLE
+		}
+		dst.appendRepeated(c, n)
+		r.off += 2
+	}
+	return nil
+}
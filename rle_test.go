@@ -0,0 +1,29 @@
+package scratch
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestRLE(t *testing.T) {
+	src := append(append([]byte("abbccc"), make([]byte, 300)...), 'd')
+	enc := NewBuf(0).AppendRLE(src)
+	want := []byte{1, 'a', 2, 'b', 3, 'c', 255, 0, 45, 0, 1, 'd'}
+	if p := enc.Bytes(); !bytes.Equal(p, want) {
+		t.Fatalf("AppendRLE() results in %#v instead of %#v", p, want)
+	}
+	dec := NewBuf(0)
+	if err := NewReader(enc.Bytes()).DecodeRLE(dec); err != nil {
+		t.Fatalf("DecodeRLE() returns error %v", err)
+	}
+	if p := dec.Bytes(); !bytes.Equal(p, src) {
+		t.Fatalf("DecodeRLE() results in %#v instead of %#v", p, src)
+	}
+	if err := NewReader([]byte{0, 'a'}).DecodeRLE(dec); err != ErrInvalidRLE {
+		t.Fatalf("DecodeRLE() of a zero count returns error %v instead of %v", err, ErrInvalidRLE)
+	}
+	if err := NewReader([]byte{1}).DecodeRLE(dec); err != io.ErrUnexpectedEOF {
+		t.Fatalf("DecodeRLE() of a truncated pair returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+}
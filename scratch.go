@@ -3,29 +3,60 @@ package scratch
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
+	"math"
+	"math/bits"
+	"strconv"
+	"time"
 	"unicode/utf8"
 	"unsafe"
 )
 
 var (
+	_ io.Reader       = (*Buf)(nil)
 	_ io.Writer       = (*Buf)(nil)
 	_ io.StringWriter = (*Buf)(nil)
 	_ io.ByteWriter   = (*Buf)(nil)
 	_ io.Closer       = (*Buf)(nil)
+	_ io.ReaderFrom   = (*Buf)(nil)
+	_ io.WriterTo     = (*Buf)(nil)
+
+	_ fmt.GoStringer = (*Buf)(nil)
 )
 
+// ErrTooLarge is returned, or used to panic, when growing the buffer would exceed its allocation limit.
+// See SetMaxAlloc.
+var ErrTooLarge = errors.New("scratch: buffer too large")
+
+// ErrMarshalMismatch is returned by MarshalVerify when a marshaled message doesn't survive a round-trip.
+var ErrMarshalMismatch = errors.New("scratch: marshaled message doesn't round-trip")
+
 // SizedMarshaler describes objects that can marshal themselves in a single allocation.
 // The most common implementations are protobuf messages.
 type SizedMarshaler interface {
 	// Size returns the maximum number of bytes required to marshal the object.
 	Size() int
-	// MarshalToSizedBuffer appends the marshaled object to the pre-sized buffer buf.
+	// MarshalToSizedBuffer marshals the object into the end of the pre-sized buffer buf.
 	// It returns the number of bytes written and any error.
+	//
+	// Like gogo/protobuf messages, the object is marshaled back-to-front
+	// so if Size() over-estimated, the output is at the end of buf.
 	MarshalToSizedBuffer(buf []byte) (int, error)
 }
 
+// VerifiableMessage describes messages that can be unmarshaled, as used by MarshalVerify.
+// The most common implementations are protobuf messages.
+type VerifiableMessage interface {
+	SizedMarshaler
+	// Unmarshal decodes data into the message.
+	Unmarshal(data []byte) error
+}
+
 // DeterministicMarshaler describes objects that can marshal themselves deterministically.
 // The most common implementations are protobuf messages.
 type DeterministicMarshaler interface {
@@ -39,31 +70,140 @@ type DeterministicMarshaler interface {
 }
 
 // Buf is a scratch buffer for working with temporary byte slices.
+//
+// A nil *Buf is treated as an empty buffer by the read-only methods Len, Cap, Bytes and String.
+// All other methods panic if the receiver is nil.
+//
+// When built with the scratchdebug tag, methods that modify a buffer panic
+// if it's used after being put back into a Pool, until it's taken out again with Get.
+// Grow also allocates guard bytes past the buffer's capacity, and Reset and Close panic
+// if they were overwritten, e.g. by unsafe code writing past the end of a slice returned by Tail.
 type Buf struct {
-	s []byte
+	debugState
+
+	s         []byte
+	growth    float64
+	maxAlloc  int
+	off       int
+	highWater int
+	pool      *Pool
+	reallocs  int
+	shared    bool // s is borrowed from the caller, see NewBufFrom
 }
 
 // Len returns the length of the buffer.
 func (b *Buf) Len() int {
+	if b == nil {
+		return 0
+	}
 	return len(b.s)
 }
 
 // Cap returns the capacity of the buffer.
 func (b *Buf) Cap() int {
+	if b == nil {
+		return 0
+	}
 	return cap(b.s)
 }
 
 // Bytes returns the buffered bytes as s[:len(s):len(s)].
 // To access the full slice, use Scratch().
+//
+// The result aliases the buffer's memory, so it should not be retained after modifying or re-using the buffer.
+// To retain the bytes, e.g. to hand them to another goroutine, use BytesCopy().
 func (b *Buf) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
 	return b.s[:len(b.s):len(b.s)]
 }
 
+// BytesCopy returns a copy of the buffered bytes, that is safe to retain after the buffer is modified or re-used.
+func (b *Buf) BytesCopy() []byte {
+	return append([]byte(nil), b.Bytes()...)
+}
+
 // String returns a copy buffered bytes as a string.
 func (b *Buf) String() string {
+	if b == nil {
+		return ""
+	}
+	return string(b.s)
+}
+
+// Key returns a copy of the buffered bytes as a string, suitable for use as a map key.
+// Unlike UnsafeString, the result is safe to retain after the buffer is modified or re-used.
+func (b *Buf) Key() string {
 	return string(b.s)
 }
 
+// GoString implements fmt.GoStringer.
+// It describes the buffer's length and capacity, and a hex preview of the first and last few bytes,
+// making it cheap enough to use with %#v on large buffers.
+func (b *Buf) GoString() string {
+	const n = 16
+	p := make([]byte, 0, 64+4*n)
+	p = append(p, "scratch.Buf{len:"...)
+	p = strconv.AppendInt(p, int64(b.Len()), 10)
+	p = append(p, ", cap:"...)
+	p = strconv.AppendInt(p, int64(b.Cap()), 10)
+	p = append(p, ", data:"...)
+	if b.Len() <= 2*n {
+		p = appendHex(p, b.s)
+	} else {
+		p = appendHex(p, b.s[:n])
+		p = append(p, "..."...)
+		p = appendHex(p, b.s[b.Len()-n:])
+	}
+	p = append(p, '}')
+	return string(p)
+}
+
+// appendHex appends the hex encoding of s to p.
+func appendHex(p, s []byte) []byte {
+	i := len(p)
+	p = append(p, make([]byte, hex.EncodedLen(len(s)))...)
+	hex.Encode(p[i:], s)
+	return p
+}
+
+// GoBytesLiteral returns the buffered bytes formatted as a Go []byte literal, for use in generated code or test fixtures.
+//
+// Printable UTF-8 text uses the compact form []byte("..."),
+// while anything else uses the form []byte{0x01, 0x02, ...}.
+func (b *Buf) GoBytesLiteral() string {
+	if isPrintable(b.s) {
+		return "[]byte(" + strconv.Quote(string(b.s)) + ")"
+	}
+	const hex = "0123456789abcdef"
+	p := make([]byte, 0, len("[]byte{}")+6*len(b.s))
+	p = append(p, "[]byte{"...)
+	for i, c := range b.s {
+		if i > 0 {
+			p = append(p, ", "...)
+		}
+		p = append(p, '0', 'x', hex[c>>4], hex[c&15])
+	}
+	p = append(p, '}')
+	return string(p)
+}
+
+// isPrintable reports whether s is valid UTF-8 made up of only printable runes and common whitespace.
+func isPrintable(s []byte) bool {
+	for len(s) > 0 {
+		r, n := utf8.DecodeRune(s)
+		if r == utf8.RuneError && n == 1 {
+			return false
+		}
+		if !strconv.IsPrint(r) && r != '\t' && r != '\n' && r != '\r' {
+			return false
+		}
+		s = s[n:]
+	}
+	return true
+}
+
 // UnsafeString returns a *reference* to the underlying slice as a string.
 //
 // NOTE: the string should not be used again after calling other methods,
@@ -72,32 +212,195 @@ func (b *Buf) UnsafeString() string {
 	return *(*string)(unsafe.Pointer(&b.s))
 }
 
+// DataPtr returns a pointer to the first byte of the underlying slice, or nil if the buffer is empty.
+// It's useful for passing the buffer to C code, along with Len(), without copying.
+//
+// NOTE: like UnsafeString, the pointer should not be used again after calling other methods,
+// of re-using the buffer, as it might no longer point to the buffer's contents.
+func (b *Buf) DataPtr() unsafe.Pointer {
+	if len(b.s) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&b.s[0])
+}
+
+// Valid reports whether the buffer consists entirely of valid UTF-8-encoded runes.
+func (b *Buf) Valid() bool {
+	return utf8.Valid(b.s)
+}
+
+// RuneCount returns the number of UTF-8-encoded runes in the buffer.
+// Erroneous and short encodings are treated as single runes of width 1 byte.
+func (b *Buf) RuneCount() int {
+	return utf8.RuneCount(b.s)
+}
+
+// Equal reports whether the buffered bytes are equal to p.
+func (b *Buf) Equal(p []byte) bool {
+	return bytes.Equal(b.s, p)
+}
+
+// Compare returns an integer comparing the buffered bytes to p lexicographically.
+// The result is 0 if they're equal, -1 if the buffered bytes sort before p and +1 if they sort after it.
+func (b *Buf) Compare(p []byte) int {
+	return bytes.Compare(b.s, p)
+}
+
+// Diff describes the first difference between the buffered bytes and p, or returns "" if they're equal.
+// The description includes the offset and a hex dump of the bytes around it, which is useful in test failures.
+func (b *Buf) Diff(p []byte) string {
+	const context = 8
+	i := 0
+	for i < len(b.s) && i < len(p) && b.s[i] == p[i] {
+		i++
+	}
+	if i == len(b.s) && i == len(p) {
+		return ""
+	}
+	window := func(name string, s []byte) string {
+		lo, hi := i-context, i+context
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(s) {
+			hi = len(s)
+		}
+		if lo > hi {
+			lo = hi
+		}
+		return fmt.Sprintf("%s[%d:%d]=%x", name, lo, hi, s[lo:hi])
+	}
+	return fmt.Sprintf("differ at offset %d (len %d vs %d): %s %s",
+		i, len(b.s), len(p), window("buf", b.s), window("other", p))
+}
+
 // Reader returns a new bytes.Reader over the underlying slice.
 func (b *Buf) Reader() *bytes.Reader {
 	return bytes.NewReader(b.s)
 }
 
+// HighWater returns the largest capacity the buffer has had.
+// Unlike Len() and Cap(), it's not affected by Reset, so it can be used to observe growth across re-use.
+func (b *Buf) HighWater() int {
+	if c := b.Cap(); c > b.highWater {
+		b.highWater = c
+	}
+	return b.highWater
+}
+
+// Reallocations returns the number of times the buffer allocated new storage to grow since it was created,
+// or since the last call to Reset, e.g. to find builds whose initial capacity is too small.
+// The initial allocation of buffers created by NewBuf isn't counted.
+func (b *Buf) Reallocations() int {
+	return b.reallocs
+}
+
 // Reset sets the buffer's length to 0 in preparation for re-use.
+// It also resets the read cursor used by GetUint64, etc., and the count of Reallocations.
 func (b *Buf) Reset() *Buf {
+	b.checkGuard()
+	if b.shared {
+		// drop the borrowed storage, as appending to it would overwrite its content
+		b.s = b.s[:0:0]
+		b.shared = false
+	}
 	b.s = b.s[:0]
+	b.off = 0
+	b.reallocs = 0
+	return b
+}
+
+// ResetTo truncates the buffer to its first n bytes in preparation for re-use.
+// It's useful for building several messages that share a common prefix,
+// by building the prefix once, then calling ResetTo(len(prefix)) before building each message.
+//
+// ResetTo panics if n is negative or greater than Len().
+func (b *Buf) ResetTo(n int) *Buf {
+	if n < 0 || n > b.Len() {
+		panic("scratch.Buf.ResetTo: length out of range")
+	}
+	if b.shared {
+		b.s = b.s[:n:n]
+	}
+	b.s = b.s[:n]
+	if b.off > n {
+		b.off = n
+	}
 	return b
 }
 
 // Grow ensures the buffer has enough capacity to fit n more bytes without re-allocation.
-// Grow panics if n is negative.
+// Grow panics if n is negative, or with ErrTooLarge if the allocation limit would be exceeded.
 func (b *Buf) Grow(n int) *Buf {
+	if err := b.GrowChecked(n); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// GrowTo ensures the buffer has enough capacity to hold a total of n bytes without re-allocation.
+// It's a no-op if n <= Cap(). See Grow.
+func (b *Buf) GrowTo(n int) *Buf {
+	if n <= b.Cap() {
+		return b
+	}
+	return b.Grow(n - b.Len())
+}
+
+// GrowChecked is like Grow, but returns ErrTooLarge instead of panicking
+// if the allocation limit would be exceeded.
+// GrowChecked panics if n is negative.
+func (b *Buf) GrowChecked(n int) error {
+	b.checkPoison()
 	if n < 0 {
 		panic("scratch.Buf.Grow: negative count")
 	}
 	if n == 0 {
-		return b
+		return nil
 	}
 	if b.Cap()-b.Len() >= n {
-		return b
+		return nil
+	}
+	if b.maxAlloc > 0 && n > b.maxAlloc-b.Len() {
+		return ErrTooLarge
 	}
-	p := make([]byte, b.Len(), b.Len()+n)
+	c := b.Len() + n
+	if g := int(float64(b.Cap()) * b.growth); g > c {
+		c = g
+		if b.maxAlloc > 0 && c > b.maxAlloc {
+			c = b.maxAlloc
+		}
+	}
+	p := b.alloc(b.Len(), c)
 	copy(p, b.s)
 	b.s = p
+	b.shared = false
+	b.reallocs++
+	if c > b.highWater {
+		b.highWater = c
+	}
+	return nil
+}
+
+// SetGrowthFactor sets the factor by which Grow scales the capacity when it must re-allocate.
+// The new capacity is f*Cap() or the minimum needed, whichever is larger.
+//
+// A factor of 1.5-2.0 trades memory for fewer re-allocations when the buffer is built progressively.
+// The default factor of 0 allocates only the minimum needed.
+func (b *Buf) SetGrowthFactor(f float64) *Buf {
+	b.growth = f
+	return b
+}
+
+// SetMaxAlloc limits the capacity that Grow will allocate to n bytes.
+// Growing beyond the limit makes Grow panic and GrowChecked return ErrTooLarge.
+// It also makes Marshal and DeterministicallyMarshal return ErrTooLarge for oversized messages.
+//
+// It's useful to guard against huge allocations when sizes come from untrusted input.
+// The limit does not apply to methods that grow via append(), like Append.
+// The default limit of 0 means no limit.
+func (b *Buf) SetMaxAlloc(n int) *Buf {
+	b.maxAlloc = n
 	return b
 }
 
@@ -105,26 +408,100 @@ func (b *Buf) Grow(n int) *Buf {
 // The underlying slice is replaced with the slice returned by f.
 //
 // It's useful as an escape hatch or to allow easy use of append() directly.
+//
+// If the buffer's storage is borrowed, see NewBufFrom, it's copied first, as f may modify it.
 func (b *Buf) Scratch(f func([]byte) []byte) *Buf {
+	b.checkPoison()
+	if b.shared {
+		b.s = append([]byte(nil), b.s...)
+		b.shared = false
+	}
 	b.s = f(b.s)
+	if b.off > len(b.s) {
+		b.off = len(b.s)
+	}
 	return b
 }
 
+// countRealloc counts a re-allocation if the capacity of the buffer isn't c anymore, e.g. after append().
+// The new storage is private, so the buffer stops sharing the slice adopted by NewBufFrom.
+func (b *Buf) countRealloc(c int) {
+	if cap(b.s) != c {
+		b.reallocs++
+		b.shared = false
+	}
+}
+
 // Append appends s to buffer.
 func (b *Buf) Append(s []byte) *Buf {
+	b.checkPoison()
+	c := cap(b.s)
 	b.s = append(b.s, s...)
+	b.countRealloc(c)
 	return b
 }
 
 // AppendString appends s to buffer.
 func (b *Buf) AppendString(s string) *Buf {
+	b.checkPoison()
+	c := cap(b.s)
 	b.s = append(b.s, s...)
+	b.countRealloc(c)
 	return b
 }
 
+// AppendStrings appends all strings in ss to the buffer, growing it only once.
+func (b *Buf) AppendStrings(ss ...string) *Buf {
+	n := 0
+	for _, s := range ss {
+		n += len(s)
+	}
+	s := b.Tail(n)
+	for _, x := range ss {
+		s = s[copy(s, x):]
+	}
+	return b
+}
+
+// AppendIf appends s to the buffer if cond is true.
+// It's useful for appending optional components without breaking a chain of calls.
+func (b *Buf) AppendIf(cond bool, s []byte) *Buf {
+	if cond {
+		b.Append(s)
+	}
+	return b
+}
+
+// AppendStringIf appends s to the buffer if cond is true.
+// See AppendIf.
+func (b *Buf) AppendStringIf(cond bool, s string) *Buf {
+	if cond {
+		b.AppendString(s)
+	}
+	return b
+}
+
+// AppendLimit appends at most max bytes of s to the buffer, and reports whether s was truncated,
+// i.e. whether it's longer than max.
+// See TruncateRunes to avoid truncating in the middle of a rune.
+func (b *Buf) AppendLimit(s []byte, max int) (truncated bool) {
+	if max < 0 {
+		max = 0
+	}
+	if len(s) <= max {
+		b.Append(s)
+		return false
+	}
+	b.Append(s[:max])
+	return true
+}
+
 // AppendByte appends c to the buffer.
 func (b *Buf) AppendByte(c byte) *Buf {
+	b.checkPoison()
+	n := cap(b.s)
 	b.s = append(b.s, c)
+	b.countRealloc(n)
 	return b
 }
 
@@ -134,6 +511,15 @@ func (b *Buf) AppendRune(r rune) *Buf {
 	return b
 }
 
+// AppendRunes appends the UTF-8 encoding of rs to the buffer.
+func (b *Buf) AppendRunes(rs []rune) *Buf {
+	b.Grow(len(rs) * utf8.UTFMax)
+	for _, r := range rs {
+		b.appendRune(r)
+	}
+	return b
+}
+
 // appendRune appends r to the buffer and returns its encoded length.
 func (b *Buf) appendRune(r rune) int {
 	if r < utf8.RuneSelf {
@@ -174,12 +560,155 @@ func (b *Buf) WriteRune(r rune) (int, error) {
 	return n, nil
 }
 
+// ReadFrom implements io.ReaderFrom.
+// It reads from r directly into the buffer until EOF, growing it as needed,
+// and returns the number of bytes read.
+// Any error except io.EOF is returned.
+func (b *Buf) ReadFrom(r io.Reader) (int64, error) {
+	const minRead = 512
+	var total int64
+	for {
+		if b.Cap()-b.Len() < minRead {
+			n := b.Cap()
+			if n < minRead {
+				n = minRead
+			}
+			if err := b.GrowChecked(n); err != nil {
+				return total, err
+			}
+		}
+		i := b.Len()
+		n, err := r.Read(b.s[i:b.Cap()])
+		if n < 0 {
+			panic("scratch.Buf.ReadFrom: reader returned negative count from Read")
+		}
+		b.s = b.s[:i+n]
+		total += int64(n)
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// Read implements io.Reader.
+// It consumes bytes from the front of the buffer, advancing the read cursor like GetUint64, etc.
+// It returns io.EOF if there are no unread bytes.
+func (b *Buf) Read(p []byte) (int, error) {
+	if b.off == len(b.s) {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := copy(p, b.s[b.off:])
+	b.off += n
+	return n, nil
+}
+
+// WriteTo implements io.WriterTo.
+// It writes the unread bytes to w, retrying short writes like WriteAllTo, and advances the read cursor past them.
+// It returns the number of bytes written.
+func (b *Buf) WriteTo(w io.Writer) (int64, error) {
+	n, err := writeAll(w, b.s[b.off:])
+	b.off += n
+	return int64(n), err
+}
+
+// AppendFromReaderAt reads exactly n bytes from r at offset off and appends them to the buffer.
+// It returns the number of bytes appended, and io.ErrUnexpectedEOF if fewer than n bytes could be read.
+// The bytes read before an error are still appended.
+func (b *Buf) AppendFromReaderAt(r io.ReaderAt, off, n int64) (int, error) {
+	if n != int64(int(n)) {
+		return 0, ErrTooLarge
+	}
+	if err := b.GrowChecked(int(n)); err != nil {
+		return 0, err
+	}
+	i := b.Len()
+	m, err := r.ReadAt(b.s[i:i+int(n)], off)
+	b.s = b.s[:i+m]
+	switch {
+	case m == int(n):
+		return m, nil
+	case err == nil || err == io.EOF:
+		return m, io.ErrUnexpectedEOF
+	}
+	return m, err
+}
+
+// WriteAllTo writes the buffered bytes to w, retrying short writes until all bytes are written or an error occurs.
+// It returns the number of bytes written.
+//
+// A write that makes no progress without returning an error fails with io.ErrShortWrite.
+// Deadlines set on w, e.g. a net.Conn, are honoured since any error returned by w stops the loop.
+// Unlike Reset, the buffer is not modified.
+func (b *Buf) WriteAllTo(w io.Writer) (int, error) {
+	return writeAll(w, b.s)
+}
+
+// writeAll writes s to w, retrying short writes. See WriteAllTo.
+func writeAll(w io.Writer, s []byte) (int, error) {
+	written := 0
+	for written < len(s) {
+		n, err := w.Write(s[written:])
+		written += n
+		switch {
+		case err == io.ErrShortWrite && n > 0:
+		case err != nil:
+			return written, err
+		case n == 0:
+			return written, io.ErrShortWrite
+		}
+	}
+	return written, nil
+}
+
+// Release puts the buffer back into the Pool it was taken from, allowing defer buf.Release()
+// without access to the pool. It's a no-op if the buffer is not from a pool, or was already put back.
+func (b *Buf) Release() {
+	if p := b.pool; p != nil {
+		p.Put(b)
+	}
+}
+
 // Close implements io.Closer as no-op.
 // Close never returns an error.
 func (b *Buf) Close() error {
+	b.checkGuard()
 	return nil
 }
 
+// SpareCapacity returns a zero-length slice over the buffer's spare capacity, i.e. s[len(s):cap(s)].
+// Unlike Tail, the buffer's length is not changed.
+//
+// It's useful for formatting directly into the buffer with functions like strconv.AppendInt.
+// Grow can be used beforehand to ensure there's enough spare capacity.
+// See Commit.
+func (b *Buf) SpareCapacity() []byte {
+	return b.s[len(b.s):]
+}
+
+// Commit extends the buffer's length by n bytes, to include bytes written into its spare capacity.
+// Commit panics if n is negative or the new length would exceed Cap().
+//
+// For example:
+//
+//	s := buf.Grow(20).SpareCapacity()
+//	s = strconv.AppendInt(s, x, 10)
+//	buf.Commit(len(s))
+//
+// If the append to s re-allocated, the bytes are not in the buffer's spare capacity, so use Append(s) instead.
+func (b *Buf) Commit(n int) *Buf {
+	if n < 0 || n > b.Cap()-b.Len() {
+		panic("scratch.Buf.Commit: length out of range")
+	}
+	b.s = b.s[:b.Len()+n]
+	return b
+}
+
 // Tail resizes the buffer to len()+n and returns a slice s[:len(s):len(s)] over the new space.
 // See also PutUint64, etc.
 func (b *Buf) Tail(n int) []byte {
@@ -190,6 +719,37 @@ func (b *Buf) Tail(n int) []byte {
 	return b.s[sp:ep]
 }
 
+// AppendFunc appends f(i) to the buffer for each i from 0 to n-1, without collecting the segments first.
+// The size of the segments isn't known in advance, so the buffer grows as needed, as by Append.
+func (b *Buf) AppendFunc(n int, f func(i int) []byte) *Buf {
+	for i := 0; i < n; i++ {
+		b.Append(f(i))
+	}
+	return b
+}
+
+// AppendRecords appends count fixed-size records of recordSize bytes each to the buffer, growing it only once.
+// fill is called for each record i with a zeroed slice rec of len recordSize to populate.
+//
+// AppendRecords panics if count or recordSize is negative.
+func (b *Buf) AppendRecords(count, recordSize int, fill func(i int, rec []byte)) *Buf {
+	if count < 0 || recordSize < 0 {
+		panic("scratch.Buf.AppendRecords: negative count")
+	}
+	const maxInt = int(^uint(0) >> 1)
+	if recordSize != 0 && count > maxInt/recordSize {
+		panic(ErrTooLarge)
+	}
+	s := b.Tail(count * recordSize)
+	for i := range s {
+		s[i] = 0
+	}
+	for i := 0; i < count; i++ {
+		fill(i, s[i*recordSize:(i+1)*recordSize:(i+1)*recordSize])
+	}
+	return b
+}
+
 // PutUint64 appends n to the buffer in big-endian order.
 func (b *Buf) PutUint64(n uint64) *Buf {
 	binary.BigEndian.PutUint64(b.Tail(8), n)
@@ -208,33 +768,366 @@ func (b *Buf) PutUint16(n uint16) *Buf {
 	return b
 }
 
+// PutUint64Order appends n to the buffer in the byte order o, e.g. binary.LittleEndian.
+func (b *Buf) PutUint64Order(n uint64, o binary.ByteOrder) *Buf {
+	o.PutUint64(b.Tail(8), n)
+	return b
+}
+
+// PutUint32Order appends n to the buffer in the byte order o, e.g. binary.LittleEndian.
+func (b *Buf) PutUint32Order(n uint32, o binary.ByteOrder) *Buf {
+	o.PutUint32(b.Tail(4), n)
+	return b
+}
+
+// PutUint16Order appends n to the buffer in the byte order o, e.g. binary.LittleEndian.
+func (b *Buf) PutUint16Order(n uint16, o binary.ByteOrder) *Buf {
+	o.PutUint16(b.Tail(2), n)
+	return b
+}
+
+// PutBool appends v to the buffer as a single byte, 1 if v is true and 0 otherwise.
+// See Reader.ReadBool for decoding.
+func (b *Buf) PutBool(v bool) *Buf {
+	if v {
+		return b.AppendByte(1)
+	}
+	return b.AppendByte(0)
+}
+
+// PutUintN appends v to the buffer as a width-byte big-endian integer, e.g. a 3-byte length field.
+//
+// PutUintN panics if width is not between 1 and 8, or if v doesn't fit in width bytes,
+// instead of silently truncating it.
+func (b *Buf) PutUintN(v uint64, width int) *Buf {
+	if width < 1 || width > 8 {
+		panic("scratch.Buf.PutUintN: width out of range")
+	}
+	if !fitsUintN(v, width) {
+		panic("scratch.Buf.PutUintN: value overflows width")
+	}
+	putUintN(b.Tail(width), v)
+	return b
+}
+
+// fitsUintN reports whether v fits in width bytes.
+func fitsUintN(v uint64, width int) bool {
+	return width >= 8 || v>>(8*uint(width)) == 0
+}
+
+// putUintN writes the low len(s) bytes of v into s in big-endian order.
+func putUintN(s []byte, v uint64) {
+	for i := len(s) - 1; i >= 0; i-- {
+		s[i] = byte(v)
+		v >>= 8
+	}
+}
+
+// PutOrderedInt64 appends n to the buffer in big-endian order, with the sign bit flipped
+// so that the bytes of negative and positive values sort in numeric order.
+// See Reader.ReadOrderedInt64 for decoding.
+func (b *Buf) PutOrderedInt64(n int64) *Buf {
+	return b.PutUint64(uint64(n) ^ 1<<63)
+}
+
+// PutOrderedFloat64 appends the IEEE 754 bits of f to the buffer in big-endian order,
+// transformed so that the bytes sort in numeric order:
+// all bits are flipped for negative values, otherwise only the sign bit is flipped.
+// See Reader.ReadOrderedFloat64 for decoding.
+//
+// -0 sorts before +0. NaNs with the sign bit set sort before -Inf and other NaNs sort after +Inf.
+func (b *Buf) PutOrderedFloat64(f float64) *Buf {
+	n := math.Float64bits(f)
+	if n&(1<<63) != 0 {
+		n = ^n
+	} else {
+		n ^= 1 << 63
+	}
+	return b.PutUint64(n)
+}
+
+// PutUnixNano appends t as Unix time in nanoseconds, in big-endian order.
+// See PutUint64.
+func (b *Buf) PutUnixNano(t time.Time) *Buf {
+	return b.PutUint64(uint64(t.UnixNano()))
+}
+
+// PutDurationNanos appends d in nanoseconds, in big-endian order.
+// See PutUint64.
+func (b *Buf) PutDurationNanos(d time.Duration) *Buf {
+	return b.PutUint64(uint64(d))
+}
+
+// AppendRandom appends n cryptographically secure random bytes from crypto/rand to the buffer,
+// e.g. for a nonce or IV. If reading random bytes fails, the buffer is left unchanged and the error is returned.
+func (b *Buf) AppendRandom(n int) (*Buf, error) {
+	i := b.Len()
+	if _, err := rand.Read(b.Tail(n)); err != nil {
+		b.s = b.s[:i]
+		return b, err
+	}
+	return b, nil
+}
+
+// AppendUvarint appends the varint encoding of n to the buffer, as used by encoding/binary and protobuf.
+// See NewReader and Reader.ReadUvarint for decoding.
+func (b *Buf) AppendUvarint(n uint64) *Buf {
+	i := b.Len()
+	j := binary.PutUvarint(b.Tail(binary.MaxVarintLen64), n)
+	b.s = b.s[:i+j]
+	return b
+}
+
+// AppendVarint appends the zigzag varint encoding of n to the buffer, as used by encoding/binary
+// and protobuf sint32/sint64 fields. Small negative values are encoded in few bytes.
+// See Reader.ReadVarint for decoding.
+//
+// For protobuf int32/int64 fields, which are not zigzag-encoded, use AppendVarintRaw.
+func (b *Buf) AppendVarint(n int64) *Buf {
+	i := b.Len()
+	j := binary.PutVarint(b.Tail(binary.MaxVarintLen64), n)
+	b.s = b.s[:i+j]
+	return b
+}
+
+// AppendVarintRaw appends the two's complement varint encoding of n to the buffer,
+// as used by protobuf int32/int64 fields. Negative values are always encoded in 10 bytes.
+// See Reader.ReadVarintRaw for decoding.
+//
+// For protobuf sint32/sint64 fields, which are zigzag-encoded, use AppendVarint.
+func (b *Buf) AppendVarintRaw(n int64) *Buf {
+	return b.AppendUvarint(uint64(n))
+}
+
+// AppendLenPrefixed appends p to the buffer, prefixed with its varint-encoded length.
+// See Reader.ReadLenPrefixed for decoding.
+func (b *Buf) AppendLenPrefixed(p []byte) *Buf {
+	return b.AppendUvarint(uint64(len(p))).Append(p)
+}
+
+// AppendBlobList appends the varint-encoded number of items, followed by each item as by AppendLenPrefixed.
+// See Reader.ReadBlobList for decoding.
+func (b *Buf) AppendBlobList(items [][]byte) *Buf {
+	b.AppendUvarint(uint64(len(items)))
+	for _, p := range items {
+		b.AppendLenPrefixed(p)
+	}
+	return b
+}
+
+// get consumes n bytes from the front of the buffer, advancing the read cursor.
+// It returns io.EOF if there are no unread bytes, or io.ErrUnexpectedEOF if there are fewer than n.
+func (b *Buf) get(n int) ([]byte, error) {
+	switch r := len(b.s) - b.off; {
+	case r == 0:
+		return nil, io.EOF
+	case r < n:
+		return nil, io.ErrUnexpectedEOF
+	}
+	s := b.s[b.off : b.off+n]
+	b.off += n
+	return s, nil
+}
+
+// GetUint64 consumes a big-endian uint64 from the front of the buffer.
+// It returns io.EOF if there are no unread bytes, or io.ErrUnexpectedEOF if there are fewer than 8.
+//
+// The read cursor is advanced past the consumed bytes, but they are not removed from the buffer.
+// Interleaving Get and Append methods is undefined unless Compact is called in-between.
+func (b *Buf) GetUint64() (uint64, error) {
+	s, err := b.get(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(s), nil
+}
+
+// GetUint32 consumes a big-endian uint32 from the front of the buffer.
+// See GetUint64 for details.
+func (b *Buf) GetUint32() (uint32, error) {
+	s, err := b.get(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(s), nil
+}
+
+// GetUint16 consumes a big-endian uint16 from the front of the buffer.
+// See GetUint64 for details.
+func (b *Buf) GetUint16() (uint16, error) {
+	s, err := b.get(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(s), nil
+}
+
+// Compact removes the bytes consumed by GetUint64, etc. from the front of the buffer
+// and resets the read cursor.
+func (b *Buf) Compact() *Buf {
+	if b.shared {
+		// slice the borrowed storage instead of moving its content
+		b.s = b.s[b.off:]
+		b.off = 0
+		return b
+	}
+	n := copy(b.s, b.s[b.off:])
+	b.s = b.s[:n]
+	b.off = 0
+	return b
+}
+
 // Marshal appends the marshaled form of msg to the buffer.
 // The most common implementations of SizedMarshaler are protobuf messages.
 func (b *Buf) Marshal(msg SizedMarshaler) error {
+	return b.marshal(msg, msg.Size())
+}
+
+// MarshalOrRollback is like Marshal, but if marshaling fails, the buffer is truncated to its length before the call,
+// so it contains only previously marshaled messages. It returns the error returned by Marshal.
+func (b *Buf) MarshalOrRollback(msg SizedMarshaler) error {
+	n := b.Len()
+	err := b.Marshal(msg)
+	if err != nil {
+		b.ResetTo(n)
+	}
+	return err
+}
+
+// MarshalVerify is like Marshal, but also checks that the marshaled form of msg round-trips:
+// it's unmarshaled into fresh, which must be an empty message of the same type, and fresh is marshaled again.
+// If the two marshaled forms differ, ErrMarshalMismatch is returned.
+// If an error is returned, the buffer is truncated to its length before the call, like MarshalOrRollback.
+//
+// As it marshals twice and unmarshals once, MarshalVerify is intended for catching bugs in custom marshalers
+// in tests or staging, not for production use. It requires marshaling to be deterministic, e.g. without map fields.
+func (b *Buf) MarshalVerify(msg SizedMarshaler, fresh VerifiableMessage) error {
+	n := b.Len()
+	err := b.marshalVerify(msg, fresh)
+	if err != nil {
+		b.ResetTo(n)
+	}
+	return err
+}
+
+func (b *Buf) marshalVerify(msg SizedMarshaler, fresh VerifiableMessage) error {
+	n := b.Len()
+	if err := b.Marshal(msg); err != nil {
+		return err
+	}
+	p := b.s[n:]
+	if err := fresh.Unmarshal(p); err != nil {
+		return err
+	}
+	q := NewBuf(fresh.Size())
+	if err := q.Marshal(fresh); err != nil {
+		return err
+	}
+	if !q.Equal(p) {
+		return ErrMarshalMismatch
+	}
+	return nil
+}
+
+// MarshalDelimited appends the marshaled form of msg to the buffer, prefixed with its varint-encoded length,
+// like protobuf's delimited format. See Reader.ReadLenPrefixed for decoding.
+// If marshaling fails, the buffer is left unchanged and the error is returned.
+func (b *Buf) MarshalDelimited(msg SizedMarshaler) error {
+	sz := msg.Size()
+	w := uvarintLen(uint64(sz))
+	if err := b.GrowChecked(w + sz); err != nil {
+		return err
+	}
 	i := b.Len()
-	s := b.Tail(msg.Size())
+	b.Tail(w)
+	if err := b.marshal(msg, sz); err != nil {
+		b.s = b.s[:i]
+		return err
+	}
+	// the length can only be shorter than reserved if Size() over-estimated
+	n := b.Len() - i - w
+	v := binary.PutUvarint(b.s[i:], uint64(n))
+	copy(b.s[i+v:], b.s[i+w:])
+	b.s = b.s[:i+v+n]
+	return nil
+}
+
+// marshal appends the marshaled form of msg, whose Size() is sz, to the buffer.
+func (b *Buf) marshal(msg SizedMarshaler, sz int) error {
+	if err := b.GrowChecked(sz); err != nil {
+		return err
+	}
+	i := b.Len()
+	s := b.Tail(sz)
 	n, err := msg.MarshalToSizedBuffer(s)
 	if err != nil {
 		return err
 	}
-	b.s = s[:i+n]
+	// the output is at the end of s, so move it to the front in case Size() over-estimated
+	copy(s, s[sz-n:])
+	b.s = b.s[:i+n]
 	return nil
 }
 
+// MarshalInto marshals msg into dst, without allocating.
+// It returns the number of bytes written to dst[:n], or ErrTooLarge if cap(dst) is less than msg.Size().
+func MarshalInto(dst []byte, msg SizedMarshaler) (int, error) {
+	sz := msg.Size()
+	if sz > cap(dst) {
+		return 0, ErrTooLarge
+	}
+	b := &Buf{s: dst[:0]}
+	if err := b.marshal(msg, sz); err != nil {
+		return 0, err
+	}
+	return b.Len(), nil
+}
+
 // DeterministicallyMarshal appends the marshaled form of msg to the buffer.
 // The most common implementations of DeterministicMarshaler are protobuf messages.
 func (b *Buf) DeterministicallyMarshal(msg DeterministicMarshaler) error {
-	b.Grow(msg.XXX_Size())
+	if err := b.GrowChecked(msg.XXX_Size()); err != nil {
+		return err
+	}
+	c := cap(b.s)
 	s, err := msg.XXX_Marshal(b.s, true)
 	if err != nil {
 		return err
 	}
 	b.s = s
+	b.countRealloc(c)
 	return nil
 }
 
 // NewBuf returns a new buffer capable of holding cap bytes without re-allocation.
 func NewBuf(cap int) *Buf {
 	b := &Buf{}
-	return b.Grow(cap)
+	b.Grow(cap)
+	b.reallocs = 0
+	return b
+}
+
+// NewBufRounded is like NewBuf, but rounds cap up to the next power of two,
+// making a re-allocation on the first Grow less likely when the buffer slightly exceeds its nominal capacity.
+func NewBufRounded(cap int) *Buf {
+	if cap <= 1 {
+		return NewBuf(cap)
+	}
+	return NewBuf(1 << bits.Len(uint(cap-1)))
+}
+
+// NewBufFrom returns a new buffer using s as its initial content.
+// The buffer adopts s without copying, so reading its content, e.g. with Bytes or GetUint64, doesn't allocate.
+//
+// The buffer's storage is copy-on-write: s, including its spare capacity, is never modified.
+// Instead, the first operation that would write into it, e.g. an Append, or an Append after ResetTo,
+// re-allocates private storage, at the cost of copying the content.
+func NewBufFrom(s []byte) *Buf {
+	return &Buf{s: s[:len(s):len(s)], shared: true}
+}
+
+// NewBufString returns a new buffer initialized with a copy of s.
+// It's useful for seeding a buffer, e.g. with content built by a strings.Builder.
+func NewBufString(s string) *Buf {
+	return NewBuf(len(s)).AppendString(s)
 }
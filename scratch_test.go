@@ -2,6 +2,11 @@ package scratch
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -16,6 +21,35 @@ func TestGrow(t *testing.T) {
 	}
 }
 
+func TestReallocations(t *testing.T) {
+	sb := NewBuf(4)
+	if n := sb.Reallocations(); n != 0 {
+		t.Fatalf("Reallocations() of a new buffer returns %d instead of 0", n)
+	}
+	sb.AppendString("abcd").AppendByte('e').Tail(100)
+	if n := sb.Reallocations(); n != 2 {
+		t.Fatalf("Reallocations() after growing twice returns %d instead of 2", n)
+	}
+	if n := sb.Reset().AppendString("abcd").Reallocations(); n != 0 {
+		t.Fatalf("Reallocations() after Reset() returns %d instead of 0", n)
+	}
+}
+
+func TestGrowTo(t *testing.T) {
+	sb := NewBuf(8).AppendString("abc")
+	for _, n := range []int{4, 8} {
+		if c := sb.GrowTo(n).Cap(); c != 8 {
+			t.Fatalf("GrowTo(%d) with Cap() 8 changes Cap() to %d", n, c)
+		}
+	}
+	if c := sb.GrowTo(20).Cap(); c < 20 {
+		t.Fatalf("GrowTo(20) results in Cap() %d instead of at least 20", c)
+	}
+	if s := sb.String(); s != "abc" {
+		t.Fatalf("GrowTo() results in %q instead of %q", s, "abc")
+	}
+}
+
 func TestTail(t *testing.T) {
 	sb := &Buf{}
 	sb.Write([]byte{1, 2, 3})
@@ -30,3 +64,511 @@ func TestTail(t *testing.T) {
 		t.Fatalf("Tail(1) results in Bytes() %#v instead of %#v", p, q)
 	}
 }
+
+func TestGrowthFactor(t *testing.T) {
+	sb := NewBuf(100).SetGrowthFactor(2)
+	sb.Tail(100)
+	sb.Grow(1)
+	if c := sb.Cap(); c != 200 {
+		t.Fatalf("Grow(1) with growth factor 2 results in cap %d instead of %d", c, 200)
+	}
+	sb.Grow(500)
+	if c, need := sb.Cap(), sb.Len()+500; c != need {
+		t.Fatalf("Grow(500) with growth factor 2 results in cap %d instead of %d", c, need)
+	}
+}
+
+func benchmarkGrowthFactor(b *testing.B, f float64) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sb := NewBuf(64).SetGrowthFactor(f)
+		for j := 0; j < 1024; j++ {
+			sb.PutUint64(uint64(j))
+		}
+	}
+}
+
+func BenchmarkGrowthFactor1(b *testing.B)   { benchmarkGrowthFactor(b, 1) }
+func BenchmarkGrowthFactor1_5(b *testing.B) { benchmarkGrowthFactor(b, 1.5) }
+func BenchmarkGrowthFactor2(b *testing.B)   { benchmarkGrowthFactor(b, 2) }
+
+func TestAppendRunes(t *testing.T) {
+	rs := []rune("héllo, 世界")
+	sb := NewBuf(0).AppendRunes(rs)
+	if s, want := sb.String(), string(rs); s != want {
+		t.Fatalf("AppendRunes(%q) results in %q", want, s)
+	}
+}
+
+func TestAppendStrings(t *testing.T) {
+	sb := NewBufString("a").AppendStrings("b", "", "cd").AppendStrings()
+	if s := sb.String(); s != "abcd" {
+		t.Fatalf("AppendStrings() results in %q instead of %q", s, "abcd")
+	}
+}
+
+func TestAppendLimit(t *testing.T) {
+	sb := NewBuf(0)
+	if sb.AppendLimit([]byte("abc"), 3) {
+		t.Fatal("AppendLimit(\"abc\", 3) reports truncation")
+	}
+	if !sb.AppendLimit([]byte("defg"), 2) {
+		t.Fatal("AppendLimit(\"defg\", 2) doesn't report truncation")
+	}
+	if s := sb.String(); s != "abcde" {
+		t.Fatalf("AppendLimit() results in %q instead of %q", s, "abcde")
+	}
+}
+
+func TestMaxAlloc(t *testing.T) {
+	sb := NewBuf(8).SetMaxAlloc(16)
+	sb.Tail(8)
+	if err := sb.GrowChecked(8); err != nil {
+		t.Fatalf("GrowChecked(8) within the limit returns error %v", err)
+	}
+	if err := sb.GrowChecked(9); err != ErrTooLarge {
+		t.Fatalf("GrowChecked(9) beyond the limit returns %v instead of %v", err, ErrTooLarge)
+	}
+}
+
+func TestGetUint(t *testing.T) {
+	sb := NewBuf(0).PutUint16(1).PutUint32(2).PutUint64(3).AppendByte(4)
+	if n, err := sb.GetUint16(); n != 1 || err != nil {
+		t.Fatalf("GetUint16() returns (%d, %v) instead of (1, nil)", n, err)
+	}
+	if n, err := sb.GetUint32(); n != 2 || err != nil {
+		t.Fatalf("GetUint32() returns (%d, %v) instead of (2, nil)", n, err)
+	}
+	if n, err := sb.GetUint64(); n != 3 || err != nil {
+		t.Fatalf("GetUint64() returns (%d, %v) instead of (3, nil)", n, err)
+	}
+	if _, err := sb.GetUint16(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("GetUint16() with 1 byte left returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	if p, q := sb.Compact().Bytes(), []byte{4}; !bytes.Equal(p, q) {
+		t.Fatalf("Compact() results in Bytes() %#v instead of %#v", p, q)
+	}
+}
+
+func TestPutUintN(t *testing.T) {
+	sb := NewBuf(0).PutUintN(0xFFFFFF, 3).PutUintN(1, 1).PutUintN(1<<63, 8)
+	want := []byte{0xff, 0xff, 0xff, 1, 0x80, 0, 0, 0, 0, 0, 0, 0}
+	if p := sb.Bytes(); !bytes.Equal(p, want) {
+		t.Fatalf("PutUintN() results in %#v instead of %#v", p, want)
+	}
+	for _, tt := range []struct {
+		v     uint64
+		width int
+	}{
+		{0x1000000, 3},
+		{0x100, 1},
+		{1, 0},
+		{1, 9},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("PutUintN(%#x, %d) did not panic", tt.v, tt.width)
+				}
+			}()
+			NewBuf(0).PutUintN(tt.v, tt.width)
+		}()
+	}
+}
+
+func TestGoString(t *testing.T) {
+	sb := NewBuf(128).Append([]byte{0xde, 0xad, 0xbe, 0xef})
+	if s, want := fmt.Sprintf("%#v", sb), "scratch.Buf{len:4, cap:128, data:deadbeef}"; s != want {
+		t.Fatalf("%%#v results in %q instead of %q", s, want)
+	}
+	sb.Tail(100)
+	want := "scratch.Buf{len:104, cap:128, data:deadbeef000000000000000000000000...00000000000000000000000000000000}"
+	if s := sb.GoString(); s != want {
+		t.Fatalf("GoString() results in %q instead of %q", s, want)
+	}
+}
+
+// shortWriter accepts at most n bytes per call to Write.
+type shortWriter struct {
+	buf bytes.Buffer
+	n   int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		p = p[:w.n]
+	}
+	return w.buf.Write(p)
+}
+
+func TestWriteAllTo(t *testing.T) {
+	sb := NewBufString("hello, world")
+	w := &shortWriter{n: 5}
+	n, err := sb.WriteAllTo(w)
+	if n != sb.Len() || err != nil {
+		t.Fatalf("WriteAllTo() returns (%d, %v) instead of (%d, nil)", n, err, sb.Len())
+	}
+	if s := w.buf.String(); s != sb.String() {
+		t.Fatalf("WriteAllTo() wrote %q instead of %q", s, sb.String())
+	}
+	if _, err := sb.WriteAllTo(&shortWriter{}); err != io.ErrShortWrite {
+		t.Fatalf("WriteAllTo() with no progress returns error %v instead of %v", err, io.ErrShortWrite)
+	}
+}
+
+func TestGoBytesLiteral(t *testing.T) {
+	tests := []struct {
+		in  []byte
+		out string
+	}{
+		{nil, `[]byte("")`},
+		{[]byte("héllo\n"), `[]byte("héllo\n")`},
+		{[]byte{0x00, 0xff, 0x10}, `[]byte{0x00, 0xff, 0x10}`},
+	}
+	for _, tt := range tests {
+		if s := NewBuf(0).Append(tt.in).GoBytesLiteral(); s != tt.out {
+			t.Fatalf("GoBytesLiteral() of %#v results in %s instead of %s", tt.in, s, tt.out)
+		}
+	}
+}
+
+func TestResetTo(t *testing.T) {
+	sb := NewBufString("header:")
+	n := sb.Len()
+	for _, msg := range []string{"first", "second"} {
+		if s, want := sb.ResetTo(n).AppendString(msg).String(), "header:"+msg; s != want {
+			t.Fatalf("ResetTo(%d) then AppendString(%q) results in %q instead of %q", n, msg, s, want)
+		}
+	}
+}
+
+// backToFrontMsg marshals itself back-to-front like gogo/protobuf messages,
+// and over-estimates its size by slack bytes.
+type backToFrontMsg struct {
+	data  string
+	slack int
+}
+
+func (m backToFrontMsg) Size() int {
+	return len(m.data) + m.slack
+}
+
+func (m backToFrontMsg) MarshalToSizedBuffer(buf []byte) (int, error) {
+	return copy(buf[len(buf)-len(m.data):], m.data), nil
+}
+
+func TestMarshal(t *testing.T) {
+	for _, slack := range []int{0, 3} {
+		sb := NewBufString("prefix:")
+		if err := sb.Marshal(backToFrontMsg{"message", slack}); err != nil {
+			t.Fatalf("Marshal() returns error %v", err)
+		}
+		if s := sb.String(); s != "prefix:message" {
+			t.Fatalf("Marshal() with slack %d results in %q instead of %q", slack, s, "prefix:message")
+		}
+	}
+}
+
+// echoMsg is a VerifiableMessage that unmarshals to its data, losing the last byte if lossy is true.
+type echoMsg struct {
+	data  string
+	lossy bool
+}
+
+func (m *echoMsg) Size() int {
+	return len(m.data)
+}
+
+func (m *echoMsg) MarshalToSizedBuffer(buf []byte) (int, error) {
+	return copy(buf[len(buf)-len(m.data):], m.data), nil
+}
+
+func (m *echoMsg) Unmarshal(data []byte) error {
+	if m.lossy && len(data) != 0 {
+		data = data[:len(data)-1]
+	}
+	m.data = string(data)
+	return nil
+}
+
+func TestMarshalVerify(t *testing.T) {
+	sb := NewBufString("prefix:")
+	if err := sb.MarshalVerify(backToFrontMsg{"message", 3}, &echoMsg{}); err != nil {
+		t.Fatalf("MarshalVerify() returns error %v", err)
+	}
+	if err := sb.MarshalVerify(backToFrontMsg{"message", 0}, &echoMsg{lossy: true}); err != ErrMarshalMismatch {
+		t.Fatalf("MarshalVerify() with a lossy round-trip returns error %v instead of %v", err, ErrMarshalMismatch)
+	}
+	if err := sb.MarshalVerify(failingMsg{}, &echoMsg{}); err != io.ErrUnexpectedEOF {
+		t.Fatalf("MarshalVerify() of a failing message returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	if s := sb.String(); s != "prefix:message" {
+		t.Fatalf("MarshalVerify() results in %q instead of %q", s, "prefix:message")
+	}
+}
+
+func TestMarshalDelimited(t *testing.T) {
+	long := strings.Repeat("x", 126)
+	sb := NewBuf(0)
+	for _, m := range []backToFrontMsg{{"message", 0}, {long, 3}, {"", 1}} {
+		if err := sb.MarshalDelimited(m); err != nil {
+			t.Fatalf("MarshalDelimited() returns error %v", err)
+		}
+	}
+	r := NewReader(sb.Bytes())
+	for _, want := range []string{"message", long, ""} {
+		if p, err := r.ReadLenPrefixed(); string(p) != want || err != nil {
+			t.Fatalf("ReadLenPrefixed() returns (%q, %v) instead of (%q, nil)", p, err, want)
+		}
+	}
+	if n := r.Remaining(); n != 0 {
+		t.Fatalf("MarshalDelimited() results in %d trailing bytes", n)
+	}
+
+	sb = NewBufString("ok")
+	if err := sb.MarshalDelimited(failingMsg{}); err != io.ErrUnexpectedEOF {
+		t.Fatalf("MarshalDelimited() of a failing message returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	if s := sb.String(); s != "ok" {
+		t.Fatalf("MarshalDelimited() of a failing message results in %q instead of %q", s, "ok")
+	}
+}
+
+// appendMsg is a DeterministicMarshaler whose XXX_Size under-estimates, so XXX_Marshal re-allocates.
+type appendMsg string
+
+func (m appendMsg) XXX_Size() int {
+	return 0
+}
+
+func (m appendMsg) XXX_Marshal(buf []byte, deterministic bool) ([]byte, error) {
+	return append(buf, m...), nil
+}
+
+func TestDeterministicallyMarshal(t *testing.T) {
+	sb := NewBufFrom([]byte("ab"))
+	if err := sb.DeterministicallyMarshal(appendMsg("cd")); err != nil {
+		t.Fatalf("DeterministicallyMarshal() returns error %v", err)
+	}
+	if s := sb.String(); s != "abcd" {
+		t.Fatalf("DeterministicallyMarshal() results in %q instead of %q", s, "abcd")
+	}
+	if n := sb.Reallocations(); n != 1 {
+		t.Fatalf("Reallocations() after XXX_Marshal re-allocated returns %d instead of 1", n)
+	}
+	c := sb.Cap()
+	if n := sb.Reset().Cap(); n != c {
+		t.Fatalf("Reset() after DeterministicallyMarshal() changes Cap() from %d to %d", c, n)
+	}
+}
+
+func TestMarshalInto(t *testing.T) {
+	dst := make([]byte, 16)
+	n, err := MarshalInto(dst, backToFrontMsg{"message", 3})
+	if err != nil {
+		t.Fatalf("MarshalInto() returns error %v", err)
+	}
+	if s := string(dst[:n]); s != "message" {
+		t.Fatalf("MarshalInto() results in %q instead of %q", s, "message")
+	}
+	if _, err := MarshalInto(dst[:0:4], backToFrontMsg{"message", 0}); err != ErrTooLarge {
+		t.Fatalf("MarshalInto() a too small slice returns error %v instead of %v", err, ErrTooLarge)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	sb := NewBufString("n=").Grow(20)
+	s := strconv.AppendInt(sb.SpareCapacity(), -12345, 10)
+	if p, want := sb.Commit(len(s)).String(), "n=-12345"; p != want {
+		t.Fatalf("Commit(%d) results in %q instead of %q", len(s), p, want)
+	}
+}
+
+func TestAppendFromReaderAt(t *testing.T) {
+	r := strings.NewReader("0123456789")
+	sb := NewBufString("x")
+	if n, err := sb.AppendFromReaderAt(r, 2, 3); n != 3 || err != nil {
+		t.Fatalf("AppendFromReaderAt(r, 2, 3) returns (%d, %v) instead of (3, nil)", n, err)
+	}
+	if n, err := sb.AppendFromReaderAt(r, 8, 3); n != 2 || err != io.ErrUnexpectedEOF {
+		t.Fatalf("AppendFromReaderAt(r, 8, 3) returns (%d, %v) instead of (2, %v)", n, err, io.ErrUnexpectedEOF)
+	}
+	if s := sb.String(); s != "x23489" {
+		t.Fatalf("AppendFromReaderAt() results in %q instead of %q", s, "x23489")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	sb := NewBufString("hello, world")
+	if d := sb.Diff([]byte("hello, world")); d != "" {
+		t.Fatalf("Diff() of equal bytes returns %q instead of \"\"", d)
+	}
+	want := "differ at offset 7 (len 12 vs 12): buf[0:12]=68656c6c6f2c20776f726c64 other[0:12]=68656c6c6f2c20576f726c64"
+	if d := sb.Diff([]byte("hello, World")); d != want {
+		t.Fatalf("Diff() returns %q instead of %q", d, want)
+	}
+	want = "differ at offset 5 (len 12 vs 5): buf[0:12]=68656c6c6f2c20776f726c64 other[0:5]=68656c6c6f"
+	if d := sb.Diff([]byte("hello")); d != want {
+		t.Fatalf("Diff() returns %q instead of %q", d, want)
+	}
+}
+
+func TestReadFrom(t *testing.T) {
+	src := bytes.Repeat([]byte("0123456789"), 1000)
+	sb := NewBufString("x")
+	n, err := io.Copy(sb, struct{ io.Reader }{bytes.NewReader(src)})
+	if n != int64(len(src)) || err != nil {
+		t.Fatalf("io.Copy() into Buf returns (%d, %v) instead of (%d, nil)", n, err, len(src))
+	}
+	if !bytes.Equal(sb.Bytes()[1:], src) {
+		t.Fatal("io.Copy() into Buf does not result in the copied bytes")
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	sb := NewBufString("xhello")
+	sb.Read(make([]byte, 1))
+	var dst bytes.Buffer
+	n, err := io.Copy(&dst, sb)
+	if n != 5 || err != nil || dst.String() != "hello" {
+		t.Fatalf("io.Copy() from Buf returns (%d, %v) and copies %q instead of (5, nil) and %q", n, err, dst.String(), "hello")
+	}
+	if n, err := sb.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Fatalf("Read() after io.Copy() returns (%d, %v) instead of (0, %v)", n, err, io.EOF)
+	}
+}
+
+func BenchmarkReadFrom(b *testing.B) {
+	src := bytes.NewReader(make([]byte, 64<<10))
+	r := &struct{ io.Reader }{src}
+	sb := NewBuf(64 << 10)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		src.Seek(0, io.SeekStart)
+		sb.Reset()
+		io.Copy(sb, r)
+	}
+}
+
+func BenchmarkWriteTo(b *testing.B) {
+	sb := NewBuf(0).Append(make([]byte, 64<<10))
+	w := &struct{ io.Writer }{ioutil.Discard}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sb.off = 0
+		io.Copy(w, sb)
+	}
+}
+
+func TestNewBufRounded(t *testing.T) {
+	for _, tt := range []struct{ in, out int }{{0, 0}, {1, 1}, {3, 4}, {64, 64}, {100, 128}} {
+		if c := NewBufRounded(tt.in).Cap(); c != tt.out {
+			t.Fatalf("NewBufRounded(%d) results in cap %d instead of %d", tt.in, c, tt.out)
+		}
+	}
+}
+
+func TestNewBufFromCopyOnWrite(t *testing.T) {
+	s := make([]byte, 4, 16)
+	copy(s, "abcd")
+	orig := s[:cap(s)]
+	want := append([]byte(nil), orig...)
+
+	sb := NewBufFrom(s)
+	if &sb.Bytes()[0] != &s[0] {
+		t.Fatal("NewBufFrom() copies s")
+	}
+	if n, err := sb.GetUint16(); n != 'a'<<8|'b' || err != nil {
+		t.Fatalf("GetUint16() returns (%#x, %v) instead of (%#x, nil)", n, err, 'a'<<8|'b')
+	}
+	sb.Compact().AppendString("e").ResetTo(1).AppendString("x").PutUint16(0)
+	if s := sb.String(); s != "cx\x00\x00" {
+		t.Fatalf("the buffer has %q instead of %q", s, "cx\x00\x00")
+	}
+	sb = NewBufFrom(s)
+	sb.Reset().AppendString("xyz")
+	sb = NewBufFrom(s)
+	sb.Scratch(func(p []byte) []byte {
+		p[0] = 'x'
+		return p
+	})
+	if !bytes.Equal(orig, want) {
+		t.Fatalf("writing to the buffer modifies the adopted slice to %q", orig)
+	}
+}
+
+func TestNewBufFromPrivateStorage(t *testing.T) {
+	sb := NewBufFrom([]byte("abcd")).AppendString("e")
+	c := sb.Cap()
+	if n := sb.Reset().Cap(); n != c {
+		t.Fatalf("Reset() after a write changes Cap() from %d to %d", c, n)
+	}
+	if n := sb.AppendString("abcde").ResetTo(1).Cap(); n != c {
+		t.Fatalf("ResetTo() after a write changes Cap() from %d to %d", c, n)
+	}
+}
+
+func TestScratchClampsCursor(t *testing.T) {
+	sb := NewBufString("abcdef")
+	sb.GetUint32()
+	sb.Scratch(func(p []byte) []byte { return p[:2] })
+	if n, err := sb.Read(make([]byte, 4)); n != 0 || err != io.EOF {
+		t.Fatalf("Read() after shrinking past the cursor returns (%d, %v) instead of (0, %v)", n, err, io.EOF)
+	}
+	if n, err := sb.WriteTo(ioutil.Discard); n != 0 || err != nil {
+		t.Fatalf("WriteTo() after shrinking past the cursor returns (%d, %v) instead of (0, nil)", n, err)
+	}
+}
+
+func TestNilBuf(t *testing.T) {
+	var sb *Buf
+	if sb.Len() != 0 || sb.Cap() != 0 || sb.Bytes() != nil || sb.String() != "" {
+		t.Fatal("read-only methods of a nil *Buf do not return zero values")
+	}
+}
+
+// failingMsg fails to marshal after writing some garbage.
+type failingMsg struct{}
+
+func (failingMsg) Size() int {
+	return 4
+}
+
+func (failingMsg) MarshalToSizedBuffer(buf []byte) (int, error) {
+	copy(buf, "junk")
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestMarshalOrRollback(t *testing.T) {
+	sb := NewBuf(0)
+	if err := sb.MarshalOrRollback(backToFrontMsg{"ok", 0}); err != nil {
+		t.Fatalf("MarshalOrRollback() returns error %v", err)
+	}
+	if err := sb.MarshalOrRollback(failingMsg{}); err != io.ErrUnexpectedEOF {
+		t.Fatalf("MarshalOrRollback() returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	if s := sb.String(); s != "ok" {
+		t.Fatalf("MarshalOrRollback() of a failing message results in %q instead of %q", s, "ok")
+	}
+}
+
+func TestAppendFunc(t *testing.T) {
+	sb := NewBuf(0).AppendFunc(3, func(i int) []byte {
+		return []byte(strconv.Itoa(i) + ",")
+	})
+	if s := sb.String(); s != "0,1,2," {
+		t.Fatalf("AppendFunc() results in %q instead of %q", s, "0,1,2,")
+	}
+}
+
+func TestAppendRecords(t *testing.T) {
+	sb := NewBufString("xxxxxxxx").Reset().AppendByte('#')
+	sb.AppendRecords(3, 2, func(i int, rec []byte) {
+		rec[1] = byte(i)
+	})
+	if p, want := sb.Bytes(), []byte{'#', 0, 0, 0, 1, 0, 2}; !bytes.Equal(p, want) {
+		t.Fatalf("AppendRecords() results in %#v instead of %#v", p, want)
+	}
+}
@@ -0,0 +1,105 @@
+package scratch
+
+import (
+	"encoding/binary"
+)
+
+// SmallSize is the number of bytes a Small can hold without allocating.
+const SmallSize = 64
+
+// Small is a buffer for tiny, short-lived byte slices like keys, with a subset of Buf's API.
+// It's backed by an inline array, so a Small on the stack avoids both allocation and pool overhead.
+// If the content outgrows the array, it transparently switches to a heap-allocated slice.
+//
+// The zero value is an empty buffer ready to use. A Small must not be copied after first use.
+type Small struct {
+	n    int
+	a    [SmallSize]byte
+	heap []byte
+}
+
+// Len returns the length of the buffer.
+func (s *Small) Len() int {
+	if s.heap != nil {
+		return len(s.heap)
+	}
+	return s.n
+}
+
+// Bytes returns the buffered bytes.
+// The slice is only valid until the next modification of the buffer.
+func (s *Small) Bytes() []byte {
+	if s.heap != nil {
+		return s.heap[:len(s.heap):len(s.heap)]
+	}
+	return s.a[:s.n:s.n]
+}
+
+// String returns a copy of the buffered bytes as a string.
+func (s *Small) String() string {
+	return string(s.Bytes())
+}
+
+// Reset sets the buffer's length to 0 in preparation for re-use.
+// Any heap-allocated slice is kept for re-use.
+func (s *Small) Reset() *Small {
+	s.n = 0
+	if s.heap != nil {
+		s.heap = s.heap[:0]
+	}
+	return s
+}
+
+// tail extends the buffer by n bytes and returns a slice over the new space.
+func (s *Small) tail(n int) []byte {
+	if s.heap == nil {
+		if s.n+n <= len(s.a) {
+			s.n += n
+			return s.a[s.n-n : s.n]
+		}
+		s.heap = append(make([]byte, 0, 2*(s.n+n)), s.a[:s.n]...)
+	}
+	i := len(s.heap)
+	if cap(s.heap)-i < n {
+		s.heap = append(s.heap, make([]byte, n)...)
+	} else {
+		s.heap = s.heap[:i+n]
+	}
+	return s.heap[i : i+n]
+}
+
+// Append appends p to the buffer.
+func (s *Small) Append(p []byte) *Small {
+	copy(s.tail(len(p)), p)
+	return s
+}
+
+// AppendString appends p to the buffer.
+func (s *Small) AppendString(p string) *Small {
+	copy(s.tail(len(p)), p)
+	return s
+}
+
+// AppendByte appends c to the buffer.
+func (s *Small) AppendByte(c byte) *Small {
+	s.tail(1)[0] = c
+	return s
+}
+
+// PutUint64 appends n to the buffer in big-endian order.
+func (s *Small) PutUint64(n uint64) *Small {
+	binary.BigEndian.PutUint64(s.tail(8), n)
+	return s
+}
+
+// PutUint32 appends n to the buffer in big-endian order.
+func (s *Small) PutUint32(n uint32) *Small {
+	binary.BigEndian.PutUint32(s.tail(4), n)
+	return s
+}
+
+// PutUint16 appends n to the buffer in big-endian order.
+func (s *Small) PutUint16(n uint16) *Small {
+	binary.BigEndian.PutUint16(s.tail(2), n)
+	return s
+}
@@ -0,0 +1,33 @@
+package scratch
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSmall(t *testing.T) {
+	var s Small
+	s.AppendString("messages").AppendByte('/').PutUint16('U')
+	if p, want := s.String(), "messages/\x00U"; p != want {
+		t.Fatalf("Small results in %q instead of %q", p, want)
+	}
+	long := strings.Repeat("x", SmallSize)
+	s.AppendString(long).PutUint32(1).PutUint64(2)
+	want := NewBufString("messages/\x00U" + long).PutUint32(1).PutUint64(2).Bytes()
+	if p := s.Bytes(); !bytes.Equal(p, want) {
+		t.Fatalf("Small results in %q after overflowing instead of %q", p, want)
+	}
+	if s.Reset().AppendString("k").String() != "k" {
+		t.Fatal("Small does not work after Reset()")
+	}
+}
+
+func BenchmarkSmallKey(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var s Small
+		s.AppendString("messages").AppendByte('/').PutUint16(uint16(i))
+		_ = s.Len()
+	}
+}
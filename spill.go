@@ -0,0 +1,125 @@
+package scratch
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+var (
+	_ io.Writer       = (*SpillBuf)(nil)
+	_ io.StringWriter = (*SpillBuf)(nil)
+	_ io.Closer       = (*SpillBuf)(nil)
+)
+
+// SpillBuf is a buffer that holds up to a threshold of bytes in memory,
+// and transparently spills the rest to a temporary file.
+//
+// It's useful for occasionally huge payloads that shouldn't be held entirely in memory.
+// Close must be called to remove the temporary file.
+type SpillBuf struct {
+	buf       *Buf
+	pool      *Pool
+	threshold int
+	f         *os.File
+	n         int64
+}
+
+// Len returns the total number of bytes written to the buffer.
+func (s *SpillBuf) Len() int64 {
+	return int64(s.buf.Len()) + s.n
+}
+
+// Spilled reports whether any bytes were written to the temporary file.
+func (s *SpillBuf) Spilled() bool {
+	return s.f != nil
+}
+
+// mem returns the in-memory buffer, getting one from the pool if necessary.
+func (s *SpillBuf) mem() *Buf {
+	if s.buf == nil {
+		if s.pool != nil {
+			s.buf = s.pool.Get()
+		} else {
+			s.buf = &Buf{}
+		}
+	}
+	return s.buf
+}
+
+// Write implements io.Writer.
+// Bytes beyond the threshold are written to a temporary file, which is created on first use.
+// Write only returns errors from creating or writing to the file.
+func (s *SpillBuf) Write(p []byte) (int, error) {
+	b := s.mem()
+	if s.f == nil {
+		n := s.threshold - b.Len()
+		if n >= len(p) {
+			b.Append(p)
+			return len(p), nil
+		}
+		if n < 0 {
+			n = 0
+		}
+		b.Append(p[:n])
+		f, err := ioutil.TempFile("", "scratch-spill-")
+		if err != nil {
+			return n, err
+		}
+		s.f = f
+		m, err := s.spill(p[n:])
+		return n + m, err
+	}
+	return s.spill(p)
+}
+
+// spill writes p to the temporary file.
+func (s *SpillBuf) spill(p []byte) (int, error) {
+	n, err := s.f.Write(p)
+	s.n += int64(n)
+	return n, err
+}
+
+// WriteString implements io.StringWriter.
+// See Write.
+func (s *SpillBuf) WriteString(p string) (int, error) {
+	return s.Write([]byte(p))
+}
+
+// Reader returns a new reader over the combined content of the in-memory buffer and the temporary file.
+// The reader is invalidated by further writes and Close.
+func (s *SpillBuf) Reader() io.Reader {
+	r := bytes.NewReader(s.buf.Bytes())
+	if s.f == nil {
+		return r
+	}
+	return io.MultiReader(r, io.NewSectionReader(s.f, 0, s.n))
+}
+
+// Close implements io.Closer.
+// It removes the temporary file, if any, and returns the in-memory buffer to the pool, if any.
+// It returns any error from closing or removing the temporary file.
+func (s *SpillBuf) Close() error {
+	if s.pool != nil && s.buf != nil {
+		s.pool.Put(s.buf)
+	}
+	s.buf = nil
+	if s.f == nil {
+		return nil
+	}
+	f := s.f
+	s.f = nil
+	s.n = 0
+	err := f.Close()
+	if e := os.Remove(f.Name()); err == nil {
+		err = e
+	}
+	return err
+}
+
+// NewSpillBuf returns a new SpillBuf, that holds up to threshold bytes in memory.
+// If pool is not nil, the in-memory buffer is taken from it, and returned to it by Close.
+func NewSpillBuf(threshold int, pool *Pool) *SpillBuf {
+	return &SpillBuf{threshold: threshold, pool: pool}
+}
@@ -0,0 +1,52 @@
+package scratch
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSpillBuf(t *testing.T) {
+	sb := NewSpillBuf(8, NewPool(8))
+	sb.WriteString("hello")
+	if sb.Spilled() {
+		t.Fatal("SpillBuf spilled before reaching the threshold")
+	}
+	sb.WriteString(", world")
+	if !sb.Spilled() {
+		t.Fatal("SpillBuf did not spill after exceeding the threshold")
+	}
+	if n := sb.Len(); n != 12 {
+		t.Fatalf("Len() returns %d instead of %d", n, 12)
+	}
+	p, err := ioutil.ReadAll(sb.Reader())
+	if err != nil {
+		t.Fatalf("reading from Reader() returns error %v", err)
+	}
+	if s := string(p); s != "hello, world" {
+		t.Fatalf("Reader() reads %q instead of %q", s, "hello, world")
+	}
+	name := sb.f.Name()
+	if err := sb.Close(); err != nil {
+		t.Fatalf("Close() returns error %v", err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Fatalf("Close() did not remove the temporary file %s", name)
+	}
+}
+
+func TestSpillBufClosed(t *testing.T) {
+	pool := NewDeterministicPool(8)
+	s := NewSpillBuf(4, pool)
+	s.WriteString("ab")
+	s.Close()
+	if n := s.Len(); n != 0 {
+		t.Fatalf("Len() after Close() returns %d instead of 0", n)
+	}
+	if p, err := ioutil.ReadAll(s.Reader()); len(p) != 0 || err != nil {
+		t.Fatalf("reading after Close() returns (%q, %v) instead of (\"\", nil)", p, err)
+	}
+	if s.buf != nil {
+		t.Fatal("Len() or Reader() after Close() takes a buffer from the pool")
+	}
+}
@@ -0,0 +1,121 @@
+package scratch
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// streamTrailerSize is the size of the trailer written by StreamFramer.Close: an 8-byte length and a 4-byte CRC-32.
+const streamTrailerSize = 12
+
+// errFramerClosed is returned when writing to a closed StreamFramer.
+var errFramerClosed = errors.New("scratch: write to closed StreamFramer")
+
+// StreamFramer writes a frame of unbounded size to an io.Writer without buffering it, as the trailer-framed form:
+// [body][8-byte length][4-byte CRC-32 (IEEE) of body], with both integers in big-endian order.
+// As the length and checksum follow the body, they're computed as it's written, and frames are decoded from the end.
+// See NewStreamFrameReader for decoding.
+type StreamFramer struct {
+	w      io.Writer
+	n      uint64
+	crc    uint32
+	closed bool
+}
+
+// Write implements io.Writer, writing p to the underlying writer as part of the frame's body.
+func (f *StreamFramer) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, errFramerClosed
+	}
+	n, err := f.w.Write(p)
+	f.n += uint64(n)
+	f.crc = crc32.Update(f.crc, crc32.IEEETable, p[:n])
+	return n, err
+}
+
+// WriteString implements io.StringWriter.
+func (f *StreamFramer) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// Len returns the number of body bytes written so far.
+func (f *StreamFramer) Len() int64 {
+	return int64(f.n)
+}
+
+// Close writes the frame's trailer to the underlying writer, which is not closed.
+// Subsequent calls are no-ops, and subsequent writes fail.
+func (f *StreamFramer) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	var a [streamTrailerSize]byte
+	binary.BigEndian.PutUint64(a[:8], f.n)
+	binary.BigEndian.PutUint32(a[8:], f.crc)
+	_, err := writeAll(f.w, a[:])
+	return err
+}
+
+// NewStreamFramer returns a new StreamFramer writing a frame to w.
+func NewStreamFramer(w io.Writer) *StreamFramer {
+	return &StreamFramer{w: w}
+}
+
+// StreamFrameReader reads the body of a frame written by StreamFramer.
+type StreamFrameReader struct {
+	r   *io.SectionReader
+	off int64
+	sum uint32
+	crc uint32
+}
+
+// Read implements io.Reader, reading the frame's body.
+// At the end of the body, it returns ErrChecksum instead of io.EOF if the body doesn't match the checksum.
+func (r *StreamFrameReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.crc = crc32.Update(r.crc, crc32.IEEETable, p[:n])
+	if err == io.EOF && r.crc != r.sum {
+		err = ErrChecksum
+	}
+	return n, err
+}
+
+// Len returns the length of the frame's body.
+func (r *StreamFrameReader) Len() int64 {
+	return r.r.Size()
+}
+
+// Offset returns the offset at which the frame starts, i.e. where the previous frame, if any, ends.
+func (r *StreamFrameReader) Offset() int64 {
+	return r.off
+}
+
+// NewStreamFrameReader returns a reader of the frame ending at offset end in r, as written by StreamFramer.
+// It returns io.ErrUnexpectedEOF if the trailer's length exceeds the available data.
+//
+// The checksum can only be verified once the body has been read in full, see StreamFrameReader.Read.
+func NewStreamFrameReader(r io.ReaderAt, end int64) (*StreamFrameReader, error) {
+	if end < streamTrailerSize {
+		return nil, io.ErrUnexpectedEOF
+	}
+	var a [streamTrailerSize]byte
+	if _, err := r.ReadAt(a[:], end-streamTrailerSize); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	n := binary.BigEndian.Uint64(a[:8])
+	if n > uint64(end-streamTrailerSize) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	off := end - streamTrailerSize - int64(n)
+	return &StreamFrameReader{
+		r:   io.NewSectionReader(r, off, int64(n)),
+		off: off,
+		sum: binary.BigEndian.Uint32(a[8:]),
+	}, nil
+}
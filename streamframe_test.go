@@ -0,0 +1,48 @@
+package scratch
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestStreamFramer(t *testing.T) {
+	sb := NewBuf(0)
+	for _, body := range []string{"first", "", "second"} {
+		f := NewStreamFramer(sb)
+		f.WriteString(body[:len(body)/2])
+		f.WriteString(body[len(body)/2:])
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close() returns error %v", err)
+		}
+		if _, err := f.Write([]byte("x")); err == nil {
+			t.Fatal("Write() after Close() did not return an error")
+		}
+	}
+
+	data := sb.Bytes()
+	end := int64(len(data))
+	for _, want := range []string{"second", "", "first"} {
+		r, err := NewStreamFrameReader(bytes.NewReader(data), end)
+		if err != nil {
+			t.Fatalf("NewStreamFrameReader() returns error %v", err)
+		}
+		if p, err := ioutil.ReadAll(r); string(p) != want || err != nil {
+			t.Fatalf("reading the frame returns (%q, %v) instead of (%q, nil)", p, err, want)
+		}
+		end = r.Offset()
+	}
+	if end != 0 {
+		t.Fatalf("the first frame starts at offset %d instead of 0", end)
+	}
+
+	if _, err := NewStreamFrameReader(bytes.NewReader(data), 4); err != io.ErrUnexpectedEOF {
+		t.Fatalf("NewStreamFrameReader() of a truncated frame returns error %v instead of %v", err, io.ErrUnexpectedEOF)
+	}
+	data[0]++
+	r, _ := NewStreamFrameReader(bytes.NewReader(data), 5+streamTrailerSize)
+	if _, err := ioutil.ReadAll(r); err != ErrChecksum {
+		t.Fatalf("reading a corrupt frame returns error %v instead of %v", err, ErrChecksum)
+	}
+}
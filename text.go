@@ -0,0 +1,129 @@
+package scratch
+
+import (
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// AppendPadded appends s to the buffer, padded with pad to at least width bytes.
+// If left is true, s is left-aligned and padding is appended after it, otherwise padding is appended before it.
+//
+// If s is longer than width, it's appended in full without truncation.
+func (b *Buf) AppendPadded(s string, width int, pad byte, left bool) *Buf {
+	n := width - len(s)
+	if left {
+		return b.AppendString(s).appendRepeated(pad, n)
+	}
+	return b.appendRepeated(pad, n).AppendString(s)
+}
+
+// appendRepeated appends n copies of c to the buffer.
+// It's a no-op if n <= 0.
+func (b *Buf) appendRepeated(c byte, n int) *Buf {
+	if n <= 0 {
+		return b
+	}
+	s := b.Tail(n)
+	for i := range s {
+		s[i] = c
+	}
+	return b
+}
+
+// AppendIntGrouped appends the decimal form of i to the buffer, with sep inserted every three digits from the right,
+// e.g. -1234567 is appended as "-1,234,567" when sep is ','.
+func (b *Buf) AppendIntGrouped(i int64, sep byte) *Buf {
+	u := uint64(i)
+	if i < 0 {
+		b.AppendByte('-')
+		u = -u
+	}
+	var a [20]byte
+	d := strconv.AppendUint(a[:0], u, 10)
+	n := len(d) % 3
+	if n == 0 {
+		n = 3
+	}
+	b.Append(d[:n])
+	for d = d[n:]; len(d) != 0; d = d[3:] {
+		b.AppendByte(sep).Append(d[:3])
+	}
+	return b
+}
+
+// AppendIntPadded appends the decimal form of i to the buffer, padded with pad to at least width bytes,
+// e.g. 42 is appended as "00042" when width is 5 and pad is '0', so that keys with non-negative values sort as text.
+// The width includes the sign, which precedes the padding, e.g. -42 is appended as "-0042".
+//
+// If the decimal form of i is longer than width, it's appended in full without truncation.
+func (b *Buf) AppendIntPadded(i int64, width int, pad byte) *Buf {
+	var a [20]byte
+	d := strconv.AppendInt(a[:0], i, 10)
+	n := width - len(d)
+	if i < 0 {
+		b.AppendByte('-')
+		d = d[1:]
+	}
+	return b.appendRepeated(pad, n).Append(d)
+}
+
+// AppendJSONNumber appends f to the buffer, formatted exactly like encoding/json formats float64 values:
+// integers and most other values use the shortest decimal form, e.g. 100 or 0.1,
+// while values smaller than 1e-6 or at least 1e21 in magnitude use the exponent form, e.g. 1e+21.
+//
+// AppendJSONNumber panics if f is NaN or ±Inf, as they have no JSON representation.
+func (b *Buf) AppendJSONNumber(f float64) *Buf {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic("scratch.Buf.AppendJSONNumber: unsupported value")
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	c := cap(b.s)
+	b.s = strconv.AppendFloat(b.s, f, format, -1, 64)
+	b.countRealloc(c)
+	if format == 'e' {
+		// clean up e-09 to e-9, like encoding/json
+		n := len(b.s)
+		if n >= 4 && b.s[n-4] == 'e' && b.s[n-3] == '-' && b.s[n-2] == '0' {
+			b.s[n-2] = b.s[n-1]
+			b.s = b.s[:n-1]
+		}
+	}
+	return b
+}
+
+// TruncateRunes truncates the buffer to at most maxBytes bytes, without cutting a UTF-8-encoded rune in half.
+// The result may be shorter than maxBytes, as the truncation backs off to the start of a rune that doesn't fit.
+// It's a no-op if Len() <= maxBytes.
+//
+// TruncateRunes panics if maxBytes is negative.
+func (b *Buf) TruncateRunes(maxBytes int) *Buf {
+	if maxBytes < 0 {
+		panic("scratch.Buf.TruncateRunes: negative count")
+	}
+	if maxBytes >= b.Len() {
+		return b
+	}
+	i := maxBytes
+	for j := 0; i > 0 && j < utf8.UTFMax-1 && !utf8.RuneStart(b.s[i]); j++ {
+		i--
+	}
+	if !utf8.RuneStart(b.s[i]) {
+		// not valid UTF-8, so there's no rune to preserve
+		i = maxBytes
+	}
+	return b.ResetTo(i)
+}
+
+// AppendLine appends s to the buffer, followed by a newline.
+func (b *Buf) AppendLine(s string) *Buf {
+	return b.AppendString(s).AppendByte('\n')
+}
+
+// AppendLineBytes appends p to the buffer, followed by a newline.
+func (b *Buf) AppendLineBytes(p []byte) *Buf {
+	return b.Append(p).AppendByte('\n')
+}
@@ -0,0 +1,104 @@
+package scratch
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestAppendPadded(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		left  bool
+		out   string
+	}{
+		{"abc", 6, true, "abc..."},
+		{"abc", 6, false, "...abc"},
+		{"abcdef", 3, true, "abcdef"},
+		{"", 2, false, ".."},
+	}
+	for _, tt := range tests {
+		if s := NewBuf(0).AppendPadded(tt.s, tt.width, '.', tt.left).String(); s != tt.out {
+			t.Fatalf("AppendPadded(%q, %d, '.', %v) results in %q instead of %q", tt.s, tt.width, tt.left, s, tt.out)
+		}
+	}
+}
+
+func TestAppendIntGrouped(t *testing.T) {
+	tests := []struct {
+		i   int64
+		out string
+	}{
+		{0, "0"},
+		{123, "123"},
+		{1234, "1,234"},
+		{-1234567, "-1,234,567"},
+		{math.MinInt64, "-9,223,372,036,854,775,808"},
+	}
+	for _, tt := range tests {
+		if s := NewBuf(0).AppendIntGrouped(tt.i, ',').String(); s != tt.out {
+			t.Fatalf("AppendIntGrouped(%d, ',') results in %q instead of %q", tt.i, s, tt.out)
+		}
+	}
+}
+
+func TestAppendIntPadded(t *testing.T) {
+	tests := []struct {
+		i     int64
+		width int
+		out   string
+	}{
+		{42, 5, "00042"},
+		{-42, 5, "-0042"},
+		{0, 1, "0"},
+		{123456, 3, "123456"},
+		{math.MinInt64, 21, "-09223372036854775808"},
+	}
+	for _, tt := range tests {
+		if s := NewBuf(0).AppendIntPadded(tt.i, tt.width, '0').String(); s != tt.out {
+			t.Fatalf("AppendIntPadded(%d, %d) results in %q instead of %q", tt.i, tt.width, s, tt.out)
+		}
+	}
+}
+
+func TestAppendJSONNumber(t *testing.T) {
+	for _, f := range []float64{0, -0.0, 1, -42, 0.1, 1e20, 1e21, 123456789e30, 1e-6, 1e-7, 5e-324, math.MaxFloat64} {
+		want, _ := json.Marshal(f)
+		if s := NewBuf(0).AppendJSONNumber(f).String(); s != string(want) {
+			t.Fatalf("AppendJSONNumber(%g) results in %q instead of %q", f, s, want)
+		}
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s   string
+		max int
+		out string
+	}{
+		{"héllo", 10, "héllo"},
+		{"héllo", 2, "h"},
+		{"héllo", 3, "hé"},
+		{"世界", 5, "世"},
+		{"世界", 0, ""},
+	}
+	for _, tt := range tests {
+		if s := NewBufString(tt.s).TruncateRunes(tt.max).String(); s != tt.out {
+			t.Fatalf("TruncateRunes(%d) of %q results in %q instead of %q", tt.max, tt.s, s, tt.out)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("TruncateRunes(-1) of an empty buffer did not panic")
+		}
+	}()
+	NewBuf(0).TruncateRunes(-1)
+}
+
+func TestAppendLine(t *testing.T) {
+	b := NewBuf(0).AppendLine("a").AppendLineBytes([]byte("b")).AppendLine("")
+	if s := b.String(); s != "a\nb\n\n" {
+		t.Fatalf("AppendLine results in %q", s)
+	}
+}
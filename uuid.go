@@ -0,0 +1,52 @@
+package scratch
+
+import (
+	"crypto/rand"
+	"io"
+	"time"
+)
+
+// AppendUUID appends the 16 raw bytes of u to the buffer.
+func (b *Buf) AppendUUID(u [16]byte) *Buf {
+	return b.Append(u[:])
+}
+
+// AppendUUIDString appends u to the buffer in the canonical lowercase hyphenated form,
+// e.g. "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+func (b *Buf) AppendUUIDString(u [16]byte) *Buf {
+	const hex = "0123456789abcdef"
+	s := b.Tail(36)
+	j := 0
+	for i, c := range u {
+		if i == 4 || i == 6 || i == 8 || i == 10 {
+			s[j] = '-'
+			j++
+		}
+		s[j], s[j+1] = hex[c>>4], hex[c&15]
+		j += 2
+	}
+	return b
+}
+
+// AppendUUIDv7 appends the 16 raw bytes of a new version 7 UUID to the buffer, as specified by RFC 9562:
+// the 48-bit Unix timestamp of t in milliseconds, followed by random bits read from rand, or crypto/rand if it's nil.
+// UUIDs created at different milliseconds sort in creation order, which makes them good database keys.
+//
+// If reading random bytes fails, the buffer is left unchanged and the error is returned.
+func (b *Buf) AppendUUIDv7(t time.Time, rand io.Reader) (*Buf, error) {
+	if rand == nil {
+		rand = cryptoRand
+	}
+	var u [16]byte
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	putUintN(u[:6], ms)
+	if _, err := io.ReadFull(rand, u[6:]); err != nil {
+		return b, err
+	}
+	u[6] = 0x70 | u[6]&0x0f // version 7
+	u[8] = 0x80 | u[8]&0x3f // variant 10
+	return b.AppendUUID(u), nil
+}
+
+// cryptoRand is crypto/rand.Reader, as the rand parameter of AppendUUIDv7 shadows the package.
+var cryptoRand = rand.Reader
@@ -0,0 +1,41 @@
+package scratch
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestAppendUUID(t *testing.T) {
+	u := [16]byte{0xf8, 0x1d, 0x4f, 0xae, 0x7d, 0xec, 0x11, 0xd0, 0xa7, 0x65, 0x00, 0xa0, 0xc9, 0x1e, 0x6b, 0xf6}
+	if s, want := NewBuf(0).AppendUUIDString(u).String(), "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"; s != want {
+		t.Fatalf("AppendUUIDString() results in %q instead of %q", s, want)
+	}
+	if p := NewBuf(0).AppendUUID(u).Bytes(); string(p) != string(u[:]) {
+		t.Fatalf("AppendUUID() results in %#v instead of %#v", p, u)
+	}
+}
+
+func TestAppendUUIDv7(t *testing.T) {
+	ts := time.Unix(0, 0x17F22E279B0*int64(time.Millisecond))
+	sb, err := NewBuf(0).AppendUUIDv7(ts, bytes.NewReader(bytes.Repeat([]byte{0xff}, 10)))
+	if err != nil {
+		t.Fatalf("AppendUUIDv7() returns error %v", err)
+	}
+	want := []byte{0x01, 0x7f, 0x22, 0xe2, 0x79, 0xb0, 0x7f, 0xff, 0xbf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	if p := sb.Bytes(); !bytes.Equal(p, want) {
+		t.Fatalf("AppendUUIDv7() results in %#v instead of %#v", p, want)
+	}
+
+	a, _ := NewBuf(0).AppendUUIDv7(ts, nil)
+	c, _ := NewBuf(0).AppendUUIDv7(ts.Add(time.Millisecond), nil)
+	if a.Compare(c.Bytes()) >= 0 {
+		t.Fatalf("AppendUUIDv7() results in %x before %x", c.Bytes(), a.Bytes())
+	}
+
+	sb, err = NewBufString("x").AppendUUIDv7(ts, bytes.NewReader(make([]byte, 3)))
+	if err != io.ErrUnexpectedEOF || sb.String() != "x" {
+		t.Fatalf("AppendUUIDv7() with a short reader returns error %v and results in %q", err, sb.String())
+	}
+}
@@ -0,0 +1,92 @@
+package scratch
+
+import (
+	"io"
+	"net"
+)
+
+var (
+	_ io.Writer       = (*BufferedWriter)(nil)
+	_ io.StringWriter = (*BufferedWriter)(nil)
+	_ io.ByteWriter   = (*BufferedWriter)(nil)
+)
+
+// BufferedWriter is like bufio.Writer, but backed by a pooled buffer that grows to fit any write.
+// The buffer is taken from the pool on the first write, and returned to it by Flush.
+type BufferedWriter struct {
+	w    io.Writer
+	pool *Pool
+	buf  *Buf
+}
+
+// Buffered returns the number of bytes that have been written into the buffer, but not yet flushed.
+func (w *BufferedWriter) Buffered() int {
+	if w.buf == nil {
+		return 0
+	}
+	return w.buf.Len()
+}
+
+// buffer returns the current buffer, getting one from the pool if necessary.
+func (w *BufferedWriter) buffer() *Buf {
+	if w.buf == nil {
+		w.buf = w.pool.Get()
+	}
+	return w.buf
+}
+
+// Write implements io.Writer.
+// Write never returns an error.
+func (w *BufferedWriter) Write(s []byte) (int, error) {
+	return w.buffer().Write(s)
+}
+
+// WriteString implements io.StringWriter.
+// WriteString never returns an error.
+func (w *BufferedWriter) WriteString(s string) (int, error) {
+	return w.buffer().WriteString(s)
+}
+
+// WriteByte implements io.ByteWriter.
+// WriteByte never returns an error.
+func (w *BufferedWriter) WriteByte(c byte) error {
+	return w.buffer().WriteByte(c)
+}
+
+// Flush writes any buffered bytes to the underlying writer and returns the buffer to the pool.
+// If an error occurs, the unwritten bytes remain buffered.
+func (w *BufferedWriter) Flush() error {
+	if w.buf == nil {
+		return nil
+	}
+	n, err := w.buf.WriteAllTo(w.w)
+	if err != nil {
+		w.buf.Scratch(func(s []byte) []byte {
+			return s[:copy(s, s[n:])]
+		})
+		return err
+	}
+	w.pool.Put(w.buf)
+	w.buf = nil
+	return nil
+}
+
+// NewBufferedWriter returns a new BufferedWriter that writes to w, using buffers from pool.
+func NewBufferedWriter(w io.Writer, pool *Pool) *BufferedWriter {
+	return &BufferedWriter{w: w, pool: pool}
+}
+
+// GatherTo writes the contents of bufs to w in order.
+// It returns the total number of bytes written.
+//
+// If w is a net.Conn that supports it, all buffers are written in a single writev system call.
+// Otherwise each buffer is written with a separate call to w.Write.
+func GatherTo(w io.Writer, bufs ...*Buf) (int64, error) {
+	v := make(net.Buffers, 0, len(bufs))
+	for _, b := range bufs {
+		if b.Len() != 0 {
+			v = append(v, b.Bytes())
+		}
+	}
+	return v.WriteTo(w)
+}
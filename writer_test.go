@@ -0,0 +1,36 @@
+package scratch
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBufferedWriter(t *testing.T) {
+	var dst bytes.Buffer
+	w := NewBufferedWriter(&dst, NewPool(4))
+	w.WriteString("hello, ")
+	w.Write([]byte("world"))
+	if dst.Len() != 0 {
+		t.Fatalf("BufferedWriter wrote %q before Flush()", dst.String())
+	}
+	if n := w.Buffered(); n != 12 {
+		t.Fatalf("Buffered() returns %d instead of %d", n, 12)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() returns error %v", err)
+	}
+	if s := dst.String(); s != "hello, world" {
+		t.Fatalf("Flush() wrote %q instead of %q", s, "hello, world")
+	}
+	if n := w.Buffered(); n != 0 {
+		t.Fatalf("Buffered() after Flush() returns %d instead of 0", n)
+	}
+}
+
+func TestGatherTo(t *testing.T) {
+	var dst bytes.Buffer
+	n, err := GatherTo(&dst, NewBufString("header|"), NewBuf(0), NewBufString("body|"), NewBufString("trailer"))
+	if want := "header|body|trailer"; n != int64(len(want)) || err != nil || dst.String() != want {
+		t.Fatalf("GatherTo() returns (%d, %v) and wrote %q instead of (%d, nil) and %q", n, err, dst.String(), len(want), want)
+	}
+}